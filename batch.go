@@ -0,0 +1,62 @@
+package longswipe
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultBatchWorkers is the number of concurrent calls Batch makes when
+// workers is not positive.
+const DefaultBatchWorkers = 4
+
+// BatchResult is the outcome of one Batch item.
+type BatchResult[In, Out any] struct {
+	Input In
+	Value Out
+	Err   error
+}
+
+// Batch calls call for every input with at most workers calls in flight and
+// returns one result per input, in input order. A failed call does not stop
+// the batch; inspect each result's Err. Once ctx is done, inputs that have not
+// started are reported with ctx.Err() instead of being called.
+//
+// SDK methods can be passed directly, e.g.
+//
+//	results := longswipe.Batch(ctx, referenceIDs, 8, client.VerifyTransactionWithContext)
+//
+// Calls made through a client are still subject to its rate limiter, retries
+// and circuit breaker.
+func Batch[In, Out any](ctx context.Context, inputs []In, workers int, call func(context.Context, In) (Out, error)) []BatchResult[In, Out] {
+	if workers <= 0 {
+		workers = DefaultBatchWorkers
+	}
+	workers = min(workers, len(inputs))
+
+	results := make([]BatchResult[In, Out], len(inputs))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				result := &results[i]
+				result.Input = inputs[i]
+				if err := ctx.Err(); err != nil {
+					result.Err = err
+					continue
+				}
+				result.Value, result.Err = call(ctx, inputs[i])
+			}
+		}()
+	}
+
+	for i := range inputs {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,251 @@
+package longswipe
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without contacting the API while the client's
+// circuit breaker is open.
+var ErrCircuitOpen = errors.New("longswipe: circuit breaker is open")
+
+// CircuitState is the state of a circuit breaker.
+type CircuitState int
+
+const (
+	// CircuitClosed lets every request through while counting failures.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects every request with ErrCircuitOpen.
+	CircuitOpen
+	// CircuitHalfOpen is entered once the cool-down has elapsed, while a
+	// HealthCheck probe decides whether to close the circuit again.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// CircuitBreakerConfig configures the client's circuit breaker. Transport
+// failures and 5xx responses count as failures; other responses count as
+// successes. Zero fields take the defaults noted below.
+type CircuitBreakerConfig struct {
+	// FailureRatio is the share of failed requests within Window that opens
+	// the circuit. Defaults to 0.5.
+	FailureRatio float64
+	// MinRequests is the number of requests needed within Window before the
+	// circuit can open. Defaults to 10.
+	MinRequests int
+	// Window is the period over which requests are counted while the
+	// circuit is closed. Defaults to one minute.
+	Window time.Duration
+	// CoolDown is how long the circuit stays open before a HealthCheck
+	// probe is attempted. Defaults to 30 seconds.
+	CoolDown time.Duration
+	// OnStateChange, if set, is called after every state transition.
+	OnStateChange func(from, to CircuitState)
+}
+
+type circuitBreaker struct {
+	config CircuitBreakerConfig
+
+	mu          sync.Mutex
+	state       CircuitState
+	openedAt    time.Time
+	windowStart time.Time
+	requests    int
+	failures    int
+}
+
+func newCircuitBreaker(config *CircuitBreakerConfig) *circuitBreaker {
+	if config == nil {
+		return nil
+	}
+	b := &circuitBreaker{config: *config}
+	if b.config.FailureRatio <= 0 {
+		b.config.FailureRatio = 0.5
+	}
+	if b.config.MinRequests <= 0 {
+		b.config.MinRequests = 10
+	}
+	if b.config.Window <= 0 {
+		b.config.Window = time.Minute
+	}
+	if b.config.CoolDown <= 0 {
+		b.config.CoolDown = 30 * time.Second
+	}
+	return b
+}
+
+// allow reports whether a request may be sent. When probe is true the
+// circuit has just moved to half-open and the caller must run the probe and
+// report its outcome through probed.
+func (b *circuitBreaker) allow(now time.Time) (probe bool, err error) {
+	b.mu.Lock()
+	switch b.state {
+	case CircuitClosed:
+		b.mu.Unlock()
+		return false, nil
+	case CircuitOpen:
+		if now.Sub(b.openedAt) < b.config.CoolDown {
+			b.mu.Unlock()
+			return false, ErrCircuitOpen
+		}
+		notify := b.setState(CircuitHalfOpen, now)
+		b.mu.Unlock()
+		notify()
+		return true, nil
+	default:
+		b.mu.Unlock()
+		return false, ErrCircuitOpen
+	}
+}
+
+// probed records the outcome of the half-open probe.
+func (b *circuitBreaker) probed(now time.Time, ok bool) {
+	b.mu.Lock()
+	to := CircuitOpen
+	if ok {
+		to = CircuitClosed
+	}
+	notify := b.setState(to, now)
+	b.mu.Unlock()
+	notify()
+}
+
+// record counts the outcome of a request sent while the circuit was closed.
+func (b *circuitBreaker) record(now time.Time, failed bool) {
+	b.mu.Lock()
+	if b.state != CircuitClosed {
+		b.mu.Unlock()
+		return
+	}
+	if now.Sub(b.windowStart) > b.config.Window {
+		b.windowStart = now
+		b.requests, b.failures = 0, 0
+	}
+	b.requests++
+	if failed {
+		b.failures++
+	}
+
+	notify := func() {}
+	if b.requests >= b.config.MinRequests &&
+		float64(b.failures)/float64(b.requests) >= b.config.FailureRatio {
+		notify = b.setState(CircuitOpen, now)
+	}
+	b.mu.Unlock()
+	notify()
+}
+
+func (b *circuitBreaker) current() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// setState must be called with b.mu held. The returned function runs the
+// state-change callback and must be called after unlocking.
+func (b *circuitBreaker) setState(to CircuitState, now time.Time) func() {
+	from := b.state
+	b.state = to
+	switch to {
+	case CircuitOpen:
+		b.openedAt = now
+	case CircuitClosed:
+		b.windowStart = now
+		b.requests, b.failures = 0, 0
+	}
+
+	if b.config.OnStateChange == nil || from == to {
+		return func() {}
+	}
+	return func() { b.config.OnStateChange(from, to) }
+}
+
+// breakerProbeTimeout bounds the HealthCheck probe started by admit.
+const breakerProbeTimeout = 5 * time.Second
+
+// admit checks the circuit breaker before an attempt, running the HealthCheck
+// probe when the circuit is ready to be tested again. The probe runs in its
+// own goroutine so that the caller's context still bounds the wait; a caller
+// that gives up leaves the probe to finish and report its outcome. Other
+// callers fail fast with ErrCircuitOpen while the probe is in flight.
+func (c *Client) admit(ctx context.Context) error {
+	if c.breaker == nil {
+		return nil
+	}
+
+	probe, err := c.breaker.allow(time.Now())
+	if err != nil || !probe {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		err := c.probe()
+		c.breaker.probed(time.Now(), err == nil)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return ErrCircuitOpen
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// probe sends a single HealthCheck request. It deliberately uses none of the
+// caller's context values, such as an idempotency key, and bypasses the
+// middleware chain, retries and hooks.
+func (c *Client) probe() error {
+	ctx, cancel := context.WithTimeout(context.Background(), breakerProbeTimeout)
+	defer cancel()
+
+	creds, err := c.loadCredentials(ctx)
+	if err != nil {
+		return err
+	}
+	header := make(http.Header)
+	header.Set("User-Agent", c.userAgent)
+	header.Set("X-Forwarded-Proto", "https")
+	header.Set("Authorization", "Bearer "+creds.PublicKey)
+	header.Set("X-API-Private-Key", creds.PrivateKey)
+
+	method, path := c.endpoint("HealthCheck", nil, nil)
+	_, err = c.send(ctx, &Request{Operation: "HealthCheck", Method: method, Path: path, Header: header}, header, nil)
+	return err
+}
+
+// recordOutcome reports an attempt to the circuit breaker.
+func (c *Client) recordOutcome(ctx context.Context, resp *Response, err error) {
+	if c.breaker == nil {
+		return
+	}
+	failed := err != nil && ctx.Err() == nil && (resp == nil || resp.StatusCode >= 500)
+	c.breaker.record(time.Now(), failed)
+}
+
+// CircuitState returns the current state of the client's circuit breaker, or
+// CircuitClosed if none is configured.
+func (c *Client) CircuitState() CircuitState {
+	if c.breaker == nil {
+		return CircuitClosed
+	}
+	return c.breaker.current()
+}
@@ -0,0 +1,185 @@
+package longswipe
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// CacheConfig enables caching of GET responses for the listed operations,
+// typically reference data such as GetAllNetwork, GetAllCurrency and
+// GetAllInvoiceCurrency. Entries are keyed by operation and path, and
+// revalidated with If-None-Match when the API sent an ETag.
+//
+// The cache sits inside the middleware chain, so middleware sees calls served
+// from the cache too; only the call that fetches or revalidates an entry sends
+// its request headers to the API.
+type CacheConfig struct {
+	// TTLs maps operation names, such as "GetAllNetwork", to how long their
+	// responses stay fresh. Operations without an entry are not cached.
+	TTLs map[string]time.Duration
+	// StaleWhileRevalidate is how long past its TTL an entry is still served
+	// while it is refreshed in the background.
+	StaleWhileRevalidate time.Duration
+	// StaleIfError is how long past its TTL an entry is served when
+	// refreshing it fails with a server or network error.
+	StaleIfError time.Duration
+}
+
+type cacheEntry struct {
+	status       int
+	header       http.Header
+	body         []byte
+	etag         string
+	expires      time.Time
+	revalidating bool
+}
+
+func (e *cacheEntry) response() *Response {
+	return &Response{
+		StatusCode: e.status,
+		Header:     e.header.Clone(),
+		Body:       bytes.Clone(e.body),
+	}
+}
+
+type responseCache struct {
+	config CacheConfig
+
+	mu sync.Mutex
+	// entries is keyed by operation, then path.
+	entries map[string]map[string]*cacheEntry
+	// generation is bumped by invalidate so in-flight refreshes started
+	// before it do not store their results.
+	generation uint64
+}
+
+func newResponseCache(config *CacheConfig) *responseCache {
+	if config == nil {
+		return nil
+	}
+	return &responseCache{
+		config:  *config,
+		entries: make(map[string]map[string]*cacheEntry),
+	}
+}
+
+// serve wraps next so that requests are served from the cache when possible.
+func (rc *responseCache) serve(next Handler) Handler {
+	return func(ctx context.Context, req *Request) (*Response, error) {
+		return rc.do(ctx, req, next)
+	}
+}
+
+func (rc *responseCache) do(ctx context.Context, req *Request, next Handler) (*Response, error) {
+	ttl, ok := rc.config.TTLs[req.Operation]
+	if !ok || req.Method != GET {
+		return next(ctx, req)
+	}
+	// Cached bodies are kept raw and decoded by each caller.
+	req.into = nil
+
+	now := time.Now()
+	rc.mu.Lock()
+	if entry := rc.entries[req.Operation][req.Path]; entry != nil {
+		switch {
+		case now.Before(entry.expires):
+			resp := entry.response()
+			rc.mu.Unlock()
+			return resp, nil
+		case now.Before(entry.expires.Add(rc.config.StaleWhileRevalidate)):
+			resp := entry.response()
+			refresh := !entry.revalidating
+			entry.revalidating = true
+			rc.mu.Unlock()
+			if refresh {
+				background := *req
+				background.Header = req.Header.Clone()
+				go rc.refresh(context.Background(), &background, next, ttl)
+			}
+			return resp, nil
+		}
+	}
+	rc.mu.Unlock()
+
+	return rc.refresh(ctx, req, next, ttl)
+}
+
+// refresh fetches req, revalidating the current entry if it has an ETag, and
+// stores the result.
+func (rc *responseCache) refresh(ctx context.Context, req *Request, next Handler, ttl time.Duration) (*Response, error) {
+	rc.mu.Lock()
+	entry := rc.entries[req.Operation][req.Path]
+	generation := rc.generation
+	rc.mu.Unlock()
+
+	if entry != nil && entry.etag != "" {
+		req.Header.Set("If-None-Match", entry.etag)
+	}
+	resp, err := next(ctx, req)
+
+	now := time.Now()
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if entry != nil {
+		entry.revalidating = false
+	}
+
+	switch {
+	case err == nil && resp.StatusCode == http.StatusNotModified && entry != nil:
+		fresh := *entry
+		fresh.expires = now.Add(ttl)
+		rc.store(generation, req, &fresh)
+		return fresh.response(), nil
+	case err == nil && resp.StatusCode >= 200 && resp.StatusCode < 300:
+		rc.store(generation, req, &cacheEntry{
+			status:  resp.StatusCode,
+			header:  resp.Header.Clone(),
+			body:    bytes.Clone(resp.Body),
+			etag:    resp.Header.Get("ETag"),
+			expires: now.Add(ttl),
+		})
+	case err != nil && entry != nil && ctx.Err() == nil && (resp == nil || resp.StatusCode >= 500) &&
+		now.Before(entry.expires.Add(rc.config.StaleIfError)):
+		return entry.response(), nil
+	}
+	return resp, err
+}
+
+// store must be called with rc.mu held.
+func (rc *responseCache) store(generation uint64, req *Request, entry *cacheEntry) {
+	if generation != rc.generation {
+		return
+	}
+	paths := rc.entries[req.Operation]
+	if paths == nil {
+		paths = make(map[string]*cacheEntry)
+		rc.entries[req.Operation] = paths
+	}
+	paths[req.Path] = entry
+}
+
+func (rc *responseCache) invalidate(operations []string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	rc.generation++
+	if len(operations) == 0 {
+		clear(rc.entries)
+		return
+	}
+	for _, operation := range operations {
+		delete(rc.entries, operation)
+	}
+}
+
+// InvalidateCache drops the cached responses of the given operations, or of
+// every operation when none are given. It is a no-op when caching is not
+// enabled.
+func (c *Client) InvalidateCache(operations ...string) {
+	if c.cache != nil {
+		c.cache.invalidate(operations)
+	}
+}
@@ -2,11 +2,19 @@ package longswipe
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"maps"
 	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -14,100 +22,466 @@ type ClientConfig struct {
 	BaseURL    string
 	PublicKey  string
 	PrivateKey string
-	Timeout    time.Duration
+	// Credentials, when set, supplies the API keys for every request instead
+	// of PublicKey and PrivateKey.
+	Credentials CredentialsProvider
+	Timeout     time.Duration
+	Retry       RetryPolicy
+
+	// HTTPClient replaces the client built by the SDK. Timeout, when set,
+	// is applied to a copy of it.
+	HTTPClient *http.Client
+	// Transport is used by the SDK-built client instead of its own
+	// *http.Transport.
+	Transport http.RoundTripper
+	// TLSConfig replaces the default TLS configuration (TLS 1.2 minimum) of
+	// the SDK-built transport.
+	TLSConfig *tls.Config
+	// RootCAs replaces the system roots used to verify the API's certificate,
+	// e.g. with the private CA of a TLS-intercepting egress proxy. Start from
+	// x509.SystemCertPool to trust both.
+	RootCAs *x509.CertPool
+	// ClientCertificates are presented to servers that request mutual TLS.
+	ClientCertificates []tls.Certificate
+	// Pins enables SPKI certificate pinning.
+	Pins *PinSet
+	// Proxy selects the proxy for each request, as http.Transport.Proxy
+	// does. Requests are sent directly when it is nil.
+	Proxy func(*http.Request) (*url.URL, error)
+	// UserAgent is prepended to the SDK's own User-Agent.
+	UserAgent string
+	// Middleware is installed on the client in order, as if passed to Use.
+	Middleware []Middleware
+	// RateLimit enables client-side rate limiting when set.
+	RateLimit *RateLimitConfig
+	// CircuitBreaker enables the circuit breaker when set.
+	CircuitBreaker *CircuitBreakerConfig
+	// Logger receives a record for every attempt when set.
+	Logger *slog.Logger
+	Log    LogConfig
+	// Tracer starts a span around every call when set.
+	Tracer Tracer
+	// Metrics records every call when set.
+	Metrics *Metrics
+	// MetricsLabels are added to every series the client records, e.g. to
+	// tell merchant accounts apart when Metrics is shared.
+	MetricsLabels map[string]string
+	// OnLowQuota, when set, is called with the endpoint group and quota
+	// whenever the remaining requests the API reports for the group drop
+	// below LowQuotaThreshold.
+	OnLowQuota        func(group string, quota Quota)
+	LowQuotaThreshold int
+	// APIVersion, when set, prefixes every endpoint path, e.g. "v2" sends
+	// requests to "/v2/merchant-integrations/...".
+	APIVersion string
+	// Routes overrides the endpoints of individual SDK operations, keyed by
+	// operation name. See DefaultRoutes.
+	Routes map[string]Route
+	// Coalesce lists the operations whose concurrent identical GET calls
+	// share a single HTTP request. Each caller's context is still honoured
+	// and its middleware still runs, but only the first caller's headers
+	// reach the API.
+	Coalesce []string
+	// Cache enables response caching for the operations it lists.
+	Cache *CacheConfig
+	// Debug dumps every HTTP request and response when set.
+	Debug *DebugConfig
+	// MaxResponseSize caps the number of bytes read from a response body.
+	// Defaults to DefaultMaxResponseSize; a negative value removes the cap.
+	MaxResponseSize int64
 }
 
+// DefaultMaxResponseSize is the response body cap used when
+// ClientConfig.MaxResponseSize is zero.
+const DefaultMaxResponseSize = 10 << 20
+
 type Client struct {
-	baseURL    string
-	publicKey  string
-	privateKey string
-	httpClient *http.Client
+	baseURL       string
+	apiPrefix     string
+	routes        map[string]Route
+	userAgent     string
+	httpClient    *http.Client
+	credentials   atomic.Pointer[CredentialsProvider]
+	retry         RetryPolicy
+	limiter       *rateLimiter
+	breaker       *circuitBreaker
+	logger        *slog.Logger
+	logConfig     LogConfig
+	tracer        Tracer
+	metrics       *Metrics
+	metricsLabels map[string]string
+	maxBodySize   int64
+	debug         *debugDumper
+	cache         *responseCache
+	flights       *flightGroup
+
+	quotaMu    sync.Mutex
+	quotas     map[string]Quota
+	onLowQuota func(group string, quota Quota)
+	quotaFloor int
+
+	mu         sync.RWMutex
+	middleware []Middleware
 }
 
 func NewClient(config ClientConfig) *Client {
+	c := &Client{
+		baseURL:       config.BaseURL,
+		apiPrefix:     versionPrefix(config.APIVersion),
+		routes:        buildRoutes(config.Routes),
+		userAgent:     buildUserAgent(config.UserAgent),
+		retry:         config.Retry.withDefaults(),
+		limiter:       newRateLimiter(config.RateLimit),
+		breaker:       newCircuitBreaker(config.CircuitBreaker),
+		logger:        config.Logger,
+		logConfig:     config.Log,
+		tracer:        config.Tracer,
+		metrics:       config.Metrics,
+		metricsLabels: maps.Clone(config.MetricsLabels),
+		maxBodySize:   config.MaxResponseSize,
+		debug:         newDebugDumper(config.Debug),
+		cache:         newResponseCache(config.Cache),
+		flights:       newFlightGroup(config.Coalesce),
+		quotas:        make(map[string]Quota),
+		onLowQuota:    config.OnLowQuota,
+		quotaFloor:    config.LowQuotaThreshold,
+		httpClient:    newHTTPClient(config),
+		middleware:    append([]Middleware(nil), config.Middleware...),
+	}
+
+	if c.maxBodySize == 0 {
+		c.maxBodySize = DefaultMaxResponseSize
+	}
+
+	credentials := config.Credentials
+	if credentials == nil {
+		credentials = NewStaticCredentials(config.PublicKey, config.PrivateKey)
+	}
+	c.SetCredentialsProvider(credentials)
+	return c
+}
+
+// NewClientWithOptions applies opts on top of config, validates the result and
+// returns a client built from it.
+func NewClientWithOptions(config ClientConfig, opts ...Option) (*Client, error) {
+	for _, opt := range opts {
+		opt(&config)
+	}
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	return NewClient(config), nil
+}
+
+func newHTTPClient(config ClientConfig) *http.Client {
+	if config.HTTPClient != nil {
+		httpClient := *config.HTTPClient
+		if config.Timeout > 0 {
+			httpClient.Timeout = config.Timeout
+		}
+		return &httpClient
+	}
+
 	if config.Timeout == 0 {
 		config.Timeout = 10 * time.Second
 	}
 
-	return &Client{
-		baseURL:    config.BaseURL,
-		publicKey:  config.PublicKey,
-		privateKey: config.PrivateKey,
-		httpClient: &http.Client{
-			Timeout: config.Timeout,
-			Transport: &http.Transport{
-				TLSClientConfig: &tls.Config{
-					MinVersion: tls.VersionTLS12,
-				},
-			},
-		},
+	transport := config.Transport
+	if transport == nil {
+		transport = &http.Transport{
+			Proxy:           config.Proxy,
+			TLSClientConfig: buildTLSConfig(config),
+		}
+	}
+
+	return &http.Client{
+		Timeout:   config.Timeout,
+		Transport: transport,
 	}
 }
 
-// doRequest performs the HTTP request and returns the status code and body bytes.
-// It never returns a non-nil *http.Response (to avoid leaking bodies); instead it
-// reads the body fully and returns the bytes so callers can decide how to handle it.
-func (c *Client) doRequest(method, path string, body interface{}) (int, []byte, error) {
-	var bodyReader io.Reader
-	if body != nil {
-		jsonBody, err := json.Marshal(body)
+func buildUserAgent(custom string) string {
+	if custom == "" {
+		return sdkUserAgent
+	}
+	return custom + " " + sdkUserAgent
+}
+
+// doRequest runs the call through the client's middleware chain and returns
+// the Response. It never returns a non-nil *http.Response (to avoid leaking
+// bodies). A successful response is decoded straight into into when it is
+// non-nil and neither cached nor shared; otherwise the body is read fully so
+// callers can decide how to handle it. An idempotency key set for this call
+// with withCallIdempotencyKey is sent with every attempt, and the call is
+// wrapped in a span when a Tracer is configured and recorded in the client's
+// Metrics and the ResponseMeta carried by ctx, if any.
+func (c *Client) doRequest(ctx context.Context, operation, method, path string, body, into interface{}) (*Response, error) {
+	req := &Request{
+		Operation: operation,
+		Method:    method,
+		Path:      path,
+		Body:      body,
+		Header:    make(http.Header),
+		into:      into,
+	}
+	if key := callIdempotencyKey(ctx); key != "" {
+		req.Header.Set("Idempotency-Key", key)
+		ctx = withCallIdempotencyKey(ctx, "")
+	}
+
+	start := time.Now()
+	ctx, span := c.startSpan(ctx, req)
+	resp, err := c.handler()(ctx, req)
+	latency := time.Since(start)
+	endSpan(span, resp, err)
+	if c.metrics != nil {
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+		}
+		c.metrics.observe(c.metricsLabels, operation, status, err != nil, latency)
+	}
+	recordResponseMeta(ctx, resp, latency)
+	return resp, err
+}
+
+// execute is the innermost Handler of the middleware chain. Each attempt first
+// checks the circuit breaker and waits for the rate limiter, if configured,
+// then loads the API keys from the client's CredentialsProvider. Failed
+// attempts are retried according to the client's RetryPolicy, and the
+// returned error is a *RequestError reporting the number of attempts made. If
+// ctx is cancelled or its deadline expires, the error wraps ctx.Err() so
+// callers can tell it apart from API failures with errors.Is.
+func (c *Client) execute(ctx context.Context, req *Request) (*Response, error) {
+	var jsonBody []byte
+	if req.Body != nil {
+		var err error
+		jsonBody, err = json.Marshal(req.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request body: %w", err)
+		}
+	}
+
+	header := req.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	header.Set("Content-Type", "application/json")
+	header.Set("User-Agent", c.userAgent)
+	header.Set("X-Forwarded-Proto", "https")
+
+	retryable := canRetry(req.Method, header)
+	for attempt := 1; ; attempt++ {
+		fail := &RequestError{
+			Method:         req.Method,
+			Endpoint:       req.Path,
+			Attempts:       attempt - 1,
+			IdempotencyKey: header.Get("Idempotency-Key"),
+		}
+
+		if err := c.admit(ctx); err != nil {
+			fail.Err = err
+			return nil, fail
+		}
+		if c.limiter != nil {
+			if err := c.limiter.wait(ctx, req); err != nil {
+				fail.Err = err
+				return nil, fail
+			}
+		}
+
+		start := time.Now()
+		creds, err := c.loadCredentials(ctx)
 		if err != nil {
-			return 0, nil, fmt.Errorf("failed to marshal request body: %w", err)
+			fail.Err = err
+			return nil, fail
+		}
+		header.Set("Authorization", "Bearer "+creds.PublicKey)
+		header.Set("X-API-Private-Key", creds.PrivateKey)
+
+		resp, err := c.send(ctx, req, header, jsonBody)
+		c.logAttempt(ctx, attemptLog{
+			req:      req,
+			attempt:  attempt,
+			header:   header,
+			body:     jsonBody,
+			resp:     resp,
+			err:      err,
+			duration: time.Since(start),
+		})
+		c.recordOutcome(ctx, resp, err)
+		if c.limiter != nil {
+			c.limiter.observe(req, resp)
+		}
+		c.observeQuota(req, resp)
+		if err == nil {
+			return resp, nil
 		}
+
+		fail.Attempts = attempt
+		fail.Err = err
+		if !retryable || attempt >= c.retry.MaxAttempts || ctx.Err() != nil {
+			return resp, fail
+		}
+		if resp != nil && !isRetryableStatus(resp.StatusCode) {
+			return resp, fail
+		}
+		if errors.Is(err, ErrNoRecordedResponse) {
+			// A cassette miss will not resolve itself on retry.
+			return resp, fail
+		}
+
+		delay := c.retry.backoff(attempt)
+		if resp != nil {
+			if after, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+				// Waiting longer than MaxDelay would block the caller for as
+				// long as the server asks, so give up instead.
+				if after > c.retry.MaxDelay {
+					return resp, fail
+				}
+				delay = after
+			}
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			fail.Err = ctx.Err()
+			return resp, fail
+		case <-timer.C:
+		}
+	}
+}
+
+// send performs a single attempt of call. The returned Response is nil when no
+// complete response was received. A successful response is decoded into
+// call's target as it is read, unless call.KeepBody is set or payload logging
+// or the debug dump needs the raw body.
+func (c *Client) send(ctx context.Context, call *Request, header http.Header, jsonBody []byte) (*Response, error) {
+	method, path := call.Method, call.Path
+
+	var bodyReader io.Reader
+	if jsonBody != nil {
 		bodyReader = bytes.NewReader(jsonBody)
 	}
 
-	req, err := http.NewRequest(method, c.baseURL+path, bodyReader)
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+c.apiPrefix+path, bodyReader)
 	if err != nil {
-		return 0, nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
-
-	req.Header.Set("Authorization", "Bearer "+c.publicKey)
-	req.Header.Set("X-API-Private-Key", c.privateKey)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", "LongSwipe-Go-SDK/v1")
-	req.Header.Set("X-Forwarded-Proto", "https")
+	req.Header = header.Clone()
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return 0, nil, fmt.Errorf("request failed: %w", err)
+		if c.debug != nil {
+			c.debug.dump(call, req, jsonBody, nil, nil, err)
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return resp.StatusCode, nil, fmt.Errorf("failed to read response body: %w", err)
+	body := &limitedReader{r: resp.Body, remaining: c.maxBodySize, limit: c.maxBodySize}
+	response := &Response{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
 	}
 
+	rawBody := call.KeepBody || c.debug != nil || (c.logger != nil && c.logConfig.Payloads)
+	if call.into != nil && resp.StatusCode < 400 && !rawBody {
+		err := json.NewDecoder(body).Decode(call.into)
+		if err == nil {
+			// Drain the rest so the connection can be reused.
+			_, err = io.Copy(io.Discard, body)
+		}
+		if err != nil {
+			// The API answered; a body that cannot be decoded is not worth
+			// retrying, so the response is returned with the error.
+			return response, bodyError(ctx, "decode", err)
+		}
+		response.decoded = true
+		return response, nil
+	}
+
+	response.Body, err = io.ReadAll(body)
+	if c.debug != nil {
+		c.debug.dump(call, req, jsonBody, resp, response.Body, err)
+	}
+	tooLarge := errors.Is(err, ErrResponseTooLarge)
+	if err != nil && !tooLarge {
+		return nil, bodyError(ctx, "read", err)
+	}
 	if resp.StatusCode >= 400 {
-		// Try to parse a JSON error with a `message` field and return that message
-		var parsed map[string]interface{}
-		if err := json.Unmarshal(bodyBytes, &parsed); err == nil {
-			if msg, ok := parsed["message"].(string); ok && msg != "" {
-				return resp.StatusCode, bodyBytes, fmt.Errorf("%s", msg)
-			}
+		// An oversized error page still reports the API error, built from
+		// the truncated body, so the sentinel errors keep matching.
+		apiErr := newAPIError(method, path, resp.StatusCode, resp.Header, response.Body)
+		if tooLarge {
+			return response, errors.Join(apiErr, err)
 		}
+		return response, apiErr
+	}
+	return response, err
+}
 
-		// fallback: return the raw body string
-		return resp.StatusCode, bodyBytes, fmt.Errorf("%s", string(bodyBytes))
+func bodyError(ctx context.Context, action string, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	if errors.Is(err, ErrResponseTooLarge) {
+		return err
 	}
+	return fmt.Errorf("failed to %s response body: %w", action, err)
+}
+
+// limitedReader reads from r until remaining bytes have been read, then fails
+// with ErrResponseTooLarge if r has more to give. A negative remaining means
+// no limit.
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+	limit     int64
+}
 
-	return resp.StatusCode, bodyBytes, nil
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return l.r.Read(p)
+	}
+	if l.remaining == 0 {
+		var probe [1]byte
+		n, err := l.r.Read(probe[:])
+		if n > 0 {
+			return 0, fmt.Errorf("%w: more than %d bytes", ErrResponseTooLarge, l.limit)
+		}
+		return 0, err
+	}
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	return n, err
 }
 
-func (c *Client) doRequestAndUnmarshal(method, path string, requestBody, responseStruct interface{}) (int, error) {
-	status, bodyBytes, err := c.doRequest(method, path, requestBody)
+func (c *Client) doRequestAndUnmarshal(ctx context.Context, operation, method, path string, requestBody, responseStruct interface{}) (int, error) {
+	resp, err := c.doRequest(ctx, operation, method, path, requestBody, responseStruct)
+	status := 0
+	if resp != nil {
+		status = resp.StatusCode
+	}
 	if err != nil {
-		// even on error we may have bodyBytes with API message; return status and error
+		// the API message, if any, is carried by the error; return status and error
 		return status, err
 	}
 
-	if responseStruct == nil {
-		// caller doesn't want the body decoded
+	if responseStruct == nil || resp.decoded {
+		// caller doesn't want the body decoded, or it was decoded as it was read
 		return status, nil
 	}
 
-	if err := json.Unmarshal(bodyBytes, responseStruct); err != nil {
+	if err := json.Unmarshal(resp.Body, responseStruct); err != nil {
 		return status, fmt.Errorf("failed to decode response: %w", err)
 	}
 
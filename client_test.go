@@ -65,10 +65,31 @@ func TestCreateNewFeature(t *testing.T) { ... }
 */
 
 import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -620,7 +641,7 @@ func setupTestServer(td TestData) *httptest.Server {
 		// Payment endpoints
 		case "/merchant-integrations/payment-request":
 			json.NewEncoder(w).Encode(td.PaymentResp)
-		case "/merchant-integrations/address-deposit-request":
+		case "/merchant-integrations/deposit-address-payment-request":
 			json.NewEncoder(w).Encode(td.DepositResp)
 		case "/merchant-integrations/request-wallet-deposit-charges":
 			json.NewEncoder(w).Encode(td.ChargeEstimateResp)
@@ -840,6 +861,67 @@ func TestClient(t *testing.T) {
 				t.Error("Expected error for non-existent customer, got nil")
 			}
 		})
+
+		t.Run("CustomerNotFoundAPIError", func(t *testing.T) {
+			_, err := client.GetCustomer("nonexistent@example.com")
+			if !errors.Is(err, ErrNotFound) {
+				t.Errorf("Expected ErrNotFound, got %v", err)
+			}
+			if errors.Is(err, ErrServer) {
+				t.Error("Did not expect ErrServer for a 404")
+			}
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("Expected *APIError, got %T", err)
+			}
+			if apiErr.StatusCode != http.StatusNotFound {
+				t.Errorf("Expected status 404, got %d", apiErr.StatusCode)
+			}
+			if apiErr.Message != "Customer not found" || apiErr.Status != "error" {
+				t.Errorf("Unexpected error envelope: %+v", apiErr.ErrorResponse)
+			}
+			if apiErr.Endpoint != "/merchant-integrations-server/fetch-customer-by-email/nonexistent@example.com" {
+				t.Errorf("Unexpected endpoint %s", apiErr.Endpoint)
+			}
+			if len(apiErr.Body) == 0 {
+				t.Error("Expected raw body to be kept")
+			}
+		})
+
+		t.Run("ContextCanceled", func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			_, err := client.GetAllNetworkWithContext(ctx)
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("Expected context.Canceled, got %v", err)
+			}
+		})
+
+		t.Run("ContextDeadlineExceeded", func(t *testing.T) {
+			slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case <-r.Context().Done():
+				case <-time.After(2 * time.Second):
+				}
+			}))
+			defer slow.Close()
+
+			slowClient := NewClient(ClientConfig{
+				BaseURL:    slow.URL,
+				PublicKey:  "test_pk",
+				PrivateKey: "test_sk",
+			})
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			_, err := slowClient.VerifyTransactionWithContext(ctx, "ref-123")
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+			}
+		})
 	})
 
 	t.Run("InvoiceOperations", func(t *testing.T) {
@@ -1090,6 +1172,2091 @@ func TestClient(t *testing.T) {
 	})
 }
 
+func TestRetryPolicy(t *testing.T) {
+	fastRetry := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+	newFlakyServer := func(failures int32, status int, calls *int32) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			if atomic.AddInt32(calls, 1) <= failures {
+				w.WriteHeader(status)
+				json.NewEncoder(w).Encode(ErrorResponse{Status: "error", Message: "try again", Code: status})
+				return
+			}
+			json.NewEncoder(w).Encode(SuccessResponse{Status: "success", Code: 200})
+		}))
+	}
+
+	t.Run("RetriesGetOnServiceUnavailable", func(t *testing.T) {
+		var calls int32
+		ts := newFlakyServer(2, http.StatusServiceUnavailable, &calls)
+		defer ts.Close()
+
+		client := NewClient(ClientConfig{BaseURL: ts.URL, Retry: fastRetry})
+		if _, err := client.HealthCheck(); err != nil {
+			t.Fatalf("HealthCheck failed: %v", err)
+		}
+		if calls != 3 {
+			t.Errorf("Expected 3 attempts, got %d", calls)
+		}
+	})
+
+	t.Run("ReportsAttemptsOnError", func(t *testing.T) {
+		var calls int32
+		ts := newFlakyServer(10, http.StatusBadGateway, &calls)
+		defer ts.Close()
+
+		client := NewClient(ClientConfig{BaseURL: ts.URL, Retry: fastRetry})
+		_, err := client.HealthCheck()
+
+		var reqErr *RequestError
+		if !errors.As(err, &reqErr) {
+			t.Fatalf("Expected *RequestError, got %v", err)
+		}
+		if reqErr.Attempts != 3 {
+			t.Errorf("Expected 3 attempts, got %d", reqErr.Attempts)
+		}
+	})
+
+	t.Run("DoesNotRetryPost", func(t *testing.T) {
+		var calls int32
+		ts := newFlakyServer(1, http.StatusServiceUnavailable, &calls)
+		defer ts.Close()
+
+		client := NewClient(ClientConfig{BaseURL: ts.URL, Retry: fastRetry})
+		if _, err := client.AddCustomer(&AddNewCustomer{Name: "New User", Email: "new@example.com"}); err == nil {
+			t.Fatal("Expected error for failed request, got nil")
+		}
+		if calls != 1 {
+			t.Errorf("Expected 1 attempt, got %d", calls)
+		}
+	})
+
+	t.Run("DoesNotRetryClientErrors", func(t *testing.T) {
+		var calls int32
+		ts := newFlakyServer(1, http.StatusBadRequest, &calls)
+		defer ts.Close()
+
+		client := NewClient(ClientConfig{BaseURL: ts.URL, Retry: fastRetry})
+		if _, err := client.HealthCheck(); err == nil {
+			t.Fatal("Expected error for bad request, got nil")
+		}
+		if calls != 1 {
+			t.Errorf("Expected 1 attempt, got %d", calls)
+		}
+	})
+
+	t.Run("HonoursRetryAfter", func(t *testing.T) {
+		var calls int32
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			json.NewEncoder(w).Encode(SuccessResponse{Status: "success", Code: 200})
+		}))
+		defer ts.Close()
+
+		client := NewClient(ClientConfig{BaseURL: ts.URL, Retry: RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Second}})
+		start := time.Now()
+		if _, err := client.HealthCheck(); err != nil {
+			t.Fatalf("HealthCheck failed: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed < time.Second {
+			t.Errorf("Expected to wait for Retry-After, retried after %v", elapsed)
+		}
+	})
+
+	t.Run("GivesUpOnLongRetryAfter", func(t *testing.T) {
+		var calls int32
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer ts.Close()
+
+		client := NewClient(ClientConfig{BaseURL: ts.URL, Retry: fastRetry})
+		start := time.Now()
+		_, err := client.HealthCheck()
+		if !errors.Is(err, ErrServer) {
+			t.Errorf("Expected ErrServer, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("Expected to return without waiting, took %v", elapsed)
+		}
+		if n := atomic.LoadInt32(&calls); n != 1 {
+			t.Errorf("Expected 1 attempt, got %d", n)
+		}
+	})
+}
+
+func TestIdempotencyKeys(t *testing.T) {
+	fastRetry := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+	var (
+		mu   sync.Mutex
+		keys []string
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		attempt := len(keys)
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/merchant-integrations-server/payout" && attempt == 1 {
+			w.WriteHeader(http.StatusGatewayTimeout)
+			return
+		}
+		json.NewEncoder(w).Encode(SuccessResponse{Status: "success", Code: 200})
+	}))
+	defer ts.Close()
+
+	client := NewClient(ClientConfig{BaseURL: ts.URL, Retry: fastRetry})
+	reset := func() {
+		mu.Lock()
+		keys = nil
+		mu.Unlock()
+	}
+
+	t.Run("GeneratedKeyReusedAcrossRetries", func(t *testing.T) {
+		reset()
+		res, err := client.PayoutToLongSwipeUser(&CustomerPayout{Amount: 10, ReferenceId: "payout-1"})
+		if err != nil {
+			t.Fatalf("PayoutToLongSwipeUser failed: %v", err)
+		}
+		if len(keys) != 2 {
+			t.Fatalf("Expected 2 attempts, got %d", len(keys))
+		}
+		if keys[0] == "" || keys[0] != keys[1] {
+			t.Errorf("Expected the same key on every attempt, got %q", keys)
+		}
+		if res.IdempotencyKey != keys[0] {
+			t.Errorf("Expected result key %q, got %q", keys[0], res.IdempotencyKey)
+		}
+	})
+
+	t.Run("CallerSuppliedKey", func(t *testing.T) {
+		reset()
+		body := generateMockPaymentRequest()
+		body.IdempotencyKey = "order-42"
+		res, err := client.PaymentRequest(body)
+		if err != nil {
+			t.Fatalf("PaymentRequest failed: %v", err)
+		}
+		if keys[0] != "order-42" || res.IdempotencyKey != "order-42" {
+			t.Errorf("Expected caller key to be used, sent %q, result %q", keys[0], res.IdempotencyKey)
+		}
+	})
+
+	t.Run("KeyOnError", func(t *testing.T) {
+		failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer failing.Close()
+
+		failingClient := NewClient(ClientConfig{BaseURL: failing.URL, Retry: fastRetry})
+		_, err := failingClient.RedeemVoucher(&RedeemRequest{VoucherCode: "LS1", Amount: 1, IdempotencyKey: "redeem-7"})
+
+		var reqErr *RequestError
+		if !errors.As(err, &reqErr) {
+			t.Fatalf("Expected *RequestError, got %v", err)
+		}
+		if reqErr.IdempotencyKey != "redeem-7" {
+			t.Errorf("Expected key on error, got %q", reqErr.IdempotencyKey)
+		}
+	})
+
+	t.Run("NotSharedThroughContext", func(t *testing.T) {
+		reset()
+		ctx := WithIdempotencyKey(context.Background(), "request-scoped")
+		client.PaymentRequestWithContext(ctx, generateMockPaymentRequest())
+		client.AddCustomerWithContext(ctx, &AddNewCustomer{Name: "Jane Doe", Email: "jane@example.com"})
+		payouts := []*CustomerPayout{{Amount: 1, ReferenceId: "a"}, {Amount: 2, ReferenceId: "b"}}
+		Batch(ctx, payouts, 2, client.PayoutToLongSwipeUserWithContext)
+
+		mu.Lock()
+		defer mu.Unlock()
+		seen := make(map[string]bool)
+		for i, key := range keys {
+			if key == "request-scoped" {
+				t.Errorf("Expected the context key not to reach call %d", i)
+			}
+			if key != "" && seen[key] {
+				t.Errorf("Expected distinct keys per call, got %q", keys)
+			}
+			seen[key] = true
+		}
+		if keys[1] != "" {
+			t.Errorf("Expected no idempotency key on AddCustomer, got %q", keys[1])
+		}
+	})
+
+	t.Run("NotSentForReads", func(t *testing.T) {
+		reset()
+		if _, err := client.HealthCheck(); err != nil {
+			t.Fatalf("HealthCheck failed: %v", err)
+		}
+		if keys[0] != "" {
+			t.Errorf("Expected no idempotency key on GET, got %q", keys[0])
+		}
+	})
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestClientOptions(t *testing.T) {
+	base := ClientConfig{PublicKey: "test_pk", PrivateKey: "test_sk"}
+
+	t.Run("TransportAndUserAgent", func(t *testing.T) {
+		var got *http.Request
+		transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			got = req
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Body:       io.NopCloser(strings.NewReader(`{"status":"success","code":200}`)),
+			}, nil
+		})
+
+		client, err := NewClientWithOptions(base,
+			WithBaseURL(SANDBOX),
+			WithTransport(transport),
+			WithUserAgent("checkout/1.2"),
+			WithTimeout(2*time.Second),
+		)
+		if err != nil {
+			t.Fatalf("NewClientWithOptions failed: %v", err)
+		}
+		if client.httpClient.Timeout != 2*time.Second {
+			t.Errorf("Expected timeout 2s, got %v", client.httpClient.Timeout)
+		}
+
+		if _, err := client.HealthCheck(); err != nil {
+			t.Fatalf("HealthCheck failed: %v", err)
+		}
+		if got.URL.Host != "sandbox.api.longswipe.com" {
+			t.Errorf("Expected sandbox host, got %s", got.URL.Host)
+		}
+		if ua := got.Header.Get("User-Agent"); ua != "checkout/1.2 "+sdkUserAgent {
+			t.Errorf("Unexpected User-Agent %q", ua)
+		}
+	})
+
+	t.Run("HTTPClient", func(t *testing.T) {
+		custom := &http.Client{Timeout: time.Minute}
+		client, err := NewClientWithOptions(base, WithBaseURL(PRODUCTION), WithHTTPClient(custom))
+		if err != nil {
+			t.Fatalf("NewClientWithOptions failed: %v", err)
+		}
+		if client.httpClient.Timeout != time.Minute {
+			t.Errorf("Expected the custom client's timeout to be kept, got %v", client.httpClient.Timeout)
+		}
+	})
+
+	t.Run("DefaultTLSMinimum", func(t *testing.T) {
+		client, err := NewClientWithOptions(base, WithBaseURL(PRODUCTION))
+		if err != nil {
+			t.Fatalf("NewClientWithOptions failed: %v", err)
+		}
+		transport := client.httpClient.Transport.(*http.Transport)
+		if transport.TLSClientConfig.MinVersion != tls.VersionTLS12 {
+			t.Errorf("Expected TLS 1.2 minimum, got %x", transport.TLSClientConfig.MinVersion)
+		}
+	})
+
+	t.Run("InvalidConfigurations", func(t *testing.T) {
+		cases := map[string][]Option{
+			"MissingBaseURL":           nil,
+			"RelativeBaseURL":          {WithBaseURL("api.longswipe.com")},
+			"NegativeTimeout":          {WithBaseURL(PRODUCTION), WithTimeout(-time.Second)},
+			"ClientAndTransport":       {WithBaseURL(PRODUCTION), WithHTTPClient(&http.Client{}), WithTransport(http.DefaultTransport)},
+			"TLSOnCustomTransport":     {WithBaseURL(PRODUCTION), WithTransport(http.DefaultTransport), WithTLSConfig(&tls.Config{})},
+			"UnknownCacheOperation":    {WithBaseURL(PRODUCTION), WithCache(CacheConfig{TTLs: map[string]time.Duration{"GetAllNetworks": time.Minute}})},
+			"UnknownCoalesceOperation": {WithBaseURL(PRODUCTION), WithCoalescing("VerifyTransactions")},
+		}
+		for name, opts := range cases {
+			if _, err := NewClientWithOptions(base, opts...); err == nil {
+				t.Errorf("%s: expected validation error, got nil", name)
+			}
+		}
+
+		if _, err := NewClientWithOptions(ClientConfig{BaseURL: PRODUCTION}); err == nil {
+			t.Error("Expected error for missing keys, got nil")
+		}
+	})
+}
+
+func TestMiddleware(t *testing.T) {
+	td := setupTestData()
+	ts := setupTestServer(td)
+	defer ts.Close()
+
+	var (
+		order      []string
+		operations []string
+		statuses   []int
+		errs       []error
+	)
+	record := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, req *Request) (*Response, error) {
+				order = append(order, name+":before")
+				resp, err := next(ctx, req)
+				order = append(order, name+":after")
+				return resp, err
+			}
+		}
+	}
+	audit := func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) (*Response, error) {
+			operations = append(operations, req.Operation)
+			req.Header.Set("X-Audit-Id", "audit-1")
+			resp, err := next(ctx, req)
+			if resp != nil {
+				statuses = append(statuses, resp.StatusCode)
+			}
+			errs = append(errs, err)
+			return resp, err
+		}
+	}
+
+	client := NewClient(ClientConfig{
+		BaseURL:    ts.URL,
+		PublicKey:  "test_pk",
+		PrivateKey: "test_sk",
+		Middleware: []Middleware{record("first")},
+	})
+	client.Use(record("second"), audit)
+
+	payout := &CustomerPayout{Amount: 10, ReferenceId: "payout-1"}
+	var seenBody interface{}
+	client.Use(func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) (*Response, error) {
+			seenBody = req.Body
+			return next(ctx, req)
+		}
+	})
+
+	if _, err := client.GetAllNetwork(); err != nil {
+		t.Fatalf("GetAllNetwork failed: %v", err)
+	}
+	want := []string{"first:before", "second:before", "second:after", "first:after"}
+	if strings.Join(order, ",") != strings.Join(want, ",") {
+		t.Errorf("Unexpected middleware order %v", order)
+	}
+
+	client.PayoutToLongSwipeUser(payout)
+	if seenBody != payout {
+		t.Errorf("Expected middleware to see the request body, got %v", seenBody)
+	}
+
+	client.GetCustomer("nonexistent@example.com")
+
+	wantOps := []string{"GetAllNetwork", "PayoutToLongSwipeUser", "GetCustomer"}
+	if strings.Join(operations, ",") != strings.Join(wantOps, ",") {
+		t.Errorf("Unexpected operations %v", operations)
+	}
+	if statuses[2] != http.StatusNotFound || !errors.Is(errs[2], ErrNotFound) {
+		t.Errorf("Expected middleware to see the 404 response and error, got %d %v", statuses[2], errs[2])
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	td := setupTestData()
+	ts := setupTestServer(td)
+	defer ts.Close()
+
+	newLimitedClient := func(config RateLimitConfig) *Client {
+		return NewClient(ClientConfig{
+			BaseURL:    ts.URL,
+			PublicKey:  "test_pk",
+			PrivateKey: "test_sk",
+			RateLimit:  &config,
+		})
+	}
+
+	t.Run("BlocksUntilTokenAvailable", func(t *testing.T) {
+		client := newLimitedClient(RateLimitConfig{RateLimit: RateLimit{Rate: 20, Burst: 1}})
+
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			if _, err := client.GetAllNetwork(); err != nil {
+				t.Fatalf("GetAllNetwork failed: %v", err)
+			}
+		}
+		if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+			t.Errorf("Expected calls to be throttled, took %v", elapsed)
+		}
+	})
+
+	t.Run("SeparateBucketsPerGroup", func(t *testing.T) {
+		client := newLimitedClient(RateLimitConfig{
+			RateLimit: RateLimit{Rate: 1, Burst: 1},
+			Buckets: map[string]RateLimit{
+				"merchant-integrations-server": {Rate: 1000, Burst: 10},
+			},
+		})
+
+		if _, err := client.GetAllNetwork(); err != nil {
+			t.Fatalf("GetAllNetwork failed: %v", err)
+		}
+		start := time.Now()
+		if _, err := client.GetAllInvoiceCurrency(); err != nil {
+			t.Fatalf("GetAllInvoiceCurrency failed: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Errorf("Expected server group to use its own bucket, waited %v", elapsed)
+		}
+	})
+
+	t.Run("RespectsContext", func(t *testing.T) {
+		client := newLimitedClient(RateLimitConfig{RateLimit: RateLimit{Rate: 0.1, Burst: 1}, PerOperation: true})
+		if _, err := client.GetAllCurrency(); err != nil {
+			t.Fatalf("GetAllCurrency failed: %v", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		_, err := client.GetAllCurrencyWithContext(ctx)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected context.DeadlineExceeded while waiting, got %v", err)
+		}
+	})
+
+	t.Run("AdaptsToTooManyRequests", func(t *testing.T) {
+		b := newTokenBucket(RateLimit{Rate: 10, Burst: 1})
+		now := time.Now()
+		b.throttle(now, 2*time.Second)
+
+		if delay := b.take(now.Add(time.Second)); delay < time.Second {
+			t.Errorf("Expected bucket to stay paused for Retry-After, got delay %v", delay)
+		}
+		if b.rate != 5 {
+			t.Errorf("Expected rate to be halved, got %v", b.rate)
+		}
+		b.relax()
+		if b.rate != 6 {
+			t.Errorf("Expected rate to recover gradually, got %v", b.rate)
+		}
+	})
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	var (
+		healthy   atomic.Bool
+		calls     int32
+		probeKeys []string
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if strings.HasSuffix(r.URL.Path, "/health") {
+			probeKeys = append(probeKeys, r.Header.Get("Idempotency-Key"))
+		}
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(SuccessResponse{Status: "success", Code: 200})
+	}))
+	defer ts.Close()
+
+	var (
+		mu          sync.Mutex
+		transitions []string
+	)
+	client := NewClient(ClientConfig{
+		BaseURL:    ts.URL,
+		PublicKey:  "test_pk",
+		PrivateKey: "test_sk",
+		Retry:      RetryPolicy{MaxAttempts: 1},
+		CircuitBreaker: &CircuitBreakerConfig{
+			FailureRatio: 0.5,
+			MinRequests:  2,
+			CoolDown:     50 * time.Millisecond,
+			OnStateChange: func(from, to CircuitState) {
+				mu.Lock()
+				transitions = append(transitions, from.String()+"->"+to.String())
+				mu.Unlock()
+			},
+		},
+	})
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetAllCurrency(); err == nil {
+			t.Fatal("Expected error from failing server, got nil")
+		}
+	}
+	if state := client.CircuitState(); state != CircuitOpen {
+		t.Fatalf("Expected open circuit, got %s", state)
+	}
+
+	before := atomic.LoadInt32(&calls)
+	_, err := client.GetAllCurrency()
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected ErrCircuitOpen, got %v", err)
+	}
+	if atomic.LoadInt32(&calls) != before {
+		t.Error("Expected open circuit to fail fast without contacting the API")
+	}
+
+	var operations []string
+	client.Use(func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) (*Response, error) {
+			operations = append(operations, req.Operation)
+			return next(ctx, req)
+		}
+	})
+
+	healthy.Store(true)
+	time.Sleep(60 * time.Millisecond)
+	if _, err := client.PayoutToLongSwipeUser(&CustomerPayout{Amount: 10, IdempotencyKey: "payout-42"}); err != nil {
+		t.Fatalf("Expected call to succeed after successful probe, got %v", err)
+	}
+	if state := client.CircuitState(); state != CircuitClosed {
+		t.Errorf("Expected closed circuit, got %s", state)
+	}
+	if len(probeKeys) != 1 || probeKeys[0] != "" {
+		t.Errorf("Expected one probe without the caller's idempotency key, got %q", probeKeys)
+	}
+	if len(operations) != 1 || operations[0] != "PayoutToLongSwipeUser" {
+		t.Errorf("Expected the probe to bypass middleware, got %v", operations)
+	}
+
+	want := "closed->open,open->half-open,half-open->closed"
+	mu.Lock()
+	if got := strings.Join(transitions, ","); got != want {
+		t.Errorf("Expected transitions %s, got %s", want, got)
+	}
+	mu.Unlock()
+
+	t.Run("ProbeOutlivesCallerDeadline", func(t *testing.T) {
+		slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !strings.HasSuffix(r.URL.Path, "/health") {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			time.Sleep(300 * time.Millisecond)
+			json.NewEncoder(w).Encode(SuccessResponse{Status: "success", Code: 200})
+		}))
+		defer slow.Close()
+
+		client := NewClient(ClientConfig{
+			BaseURL:        slow.URL,
+			PublicKey:      "test_pk",
+			PrivateKey:     "test_sk",
+			Retry:          RetryPolicy{MaxAttempts: 1},
+			CircuitBreaker: &CircuitBreakerConfig{MinRequests: 1, CoolDown: 10 * time.Millisecond},
+		})
+		client.GetAllCurrency()
+		time.Sleep(20 * time.Millisecond)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		_, err := client.GetAllCurrencyWithContext(ctx)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+			t.Errorf("Expected the caller's deadline to cut the wait short, took %v", elapsed)
+		}
+
+		if _, err := client.GetAllCurrency(); !errors.Is(err, ErrCircuitOpen) {
+			t.Errorf("Expected ErrCircuitOpen while the probe is in flight, got %v", err)
+		}
+		deadline := time.Now().Add(2 * time.Second)
+		for client.CircuitState() != CircuitClosed && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if state := client.CircuitState(); state != CircuitClosed {
+			t.Errorf("Expected the background probe to close the circuit, got %s", state)
+		}
+	})
+}
+
+func TestLogging(t *testing.T) {
+	td := setupTestData()
+	ts := setupTestServer(td)
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	client := NewClient(ClientConfig{
+		BaseURL:    ts.URL,
+		PublicKey:  "test_pk_secret",
+		PrivateKey: "test_sk_secret",
+		Logger:     logger,
+		Log:        LogConfig{Payloads: true},
+	})
+
+	client.RedeemVoucher(&RedeemRequest{
+		VoucherCode: td.Voucher.Code,
+		Amount:      50,
+		LockPin:     "987654",
+		MetaData:    map[string]string{"email": "jane.doe@example.com"},
+	})
+	client.GetCustomer("johndoe@gmail.com")
+
+	out := buf.String()
+	for _, secret := range []string{"test_pk_secret", "test_sk_secret", "987654", "jane.doe@example.com", "johndoe@gmail.com"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("Expected %q to be redacted from logs", secret)
+		}
+	}
+	for _, want := range []string{`"operation":"RedeemVoucher"`, `"operation":"GetCustomer"`, `"status":200`, `"attempt":1`, "j***@gmail.com"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected logs to contain %s, got:\n%s", want, out)
+		}
+	}
+
+	t.Run("UnmaskedEmails", func(t *testing.T) {
+		buf.Reset()
+		client := NewClient(ClientConfig{
+			BaseURL:    ts.URL,
+			PublicKey:  "test_pk",
+			PrivateKey: "test_sk",
+			Logger:     logger,
+			Log:        LogConfig{Level: slog.LevelInfo, EmailMasking: MaskEmailsNone},
+		})
+		client.GetCustomer("johndoe@gmail.com")
+		if !strings.Contains(buf.String(), "johndoe@gmail.com") || !strings.Contains(buf.String(), `"level":"INFO"`) {
+			t.Errorf("Expected unmasked email at info level, got:\n%s", buf.String())
+		}
+	})
+}
+
+type testSpan struct {
+	name  string
+	attrs map[string]interface{}
+	err   error
+	ended bool
+}
+
+func (s *testSpan) SetAttributes(attrs ...Attribute) {
+	for _, a := range attrs {
+		s.attrs[a.Key] = a.Value
+	}
+}
+
+func (s *testSpan) TraceParent() string {
+	return "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+}
+
+func (s *testSpan) End(err error) {
+	s.err = err
+	s.ended = true
+}
+
+type testTracer struct {
+	spans []*testSpan
+}
+
+func (tr *testTracer) StartSpan(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span) {
+	span := &testSpan{name: name, attrs: map[string]interface{}{}}
+	span.SetAttributes(attrs...)
+	tr.spans = append(tr.spans, span)
+	return ctx, span
+}
+
+func TestTracing(t *testing.T) {
+	var traceparents []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparents = append(traceparents, r.Header.Get("traceparent"))
+		json.NewEncoder(w).Encode(SuccessResponse{Status: "success", Code: 200})
+	}))
+	defer ts.Close()
+
+	tracer := &testTracer{}
+	client := NewClient(ClientConfig{BaseURL: ts.URL, PublicKey: "test_pk", PrivateKey: "test_sk", Tracer: tracer})
+
+	if _, err := client.VerifyTransaction("ref-123"); err != nil {
+		t.Fatalf("VerifyTransaction failed: %v", err)
+	}
+
+	span := tracer.spans[0]
+	if span.name != "longswipe.VerifyTransaction" || !span.ended {
+		t.Errorf("Unexpected span %+v", span)
+	}
+	if span.attrs[AttrReferenceID] != "ref-123" || span.attrs[AttrHTTPStatus] != 200 || span.attrs[AttrOperation] != "VerifyTransaction" {
+		t.Errorf("Unexpected span attributes %v", span.attrs)
+	}
+	if traceparents[0] != span.TraceParent() {
+		t.Errorf("Expected span traceparent to be propagated, got %q", traceparents[0])
+	}
+
+	t.Run("PropagatesContextTraceParent", func(t *testing.T) {
+		plain := NewClient(ClientConfig{BaseURL: ts.URL, PublicKey: "test_pk", PrivateKey: "test_sk"})
+		tp := "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"
+
+		plain.GetAllNetworkWithContext(WithTraceParent(context.Background(), tp))
+		plain.GetAllNetworkWithContext(WithTraceParent(context.Background(), "not-a-traceparent"))
+
+		if traceparents[1] != tp || traceparents[2] != "" {
+			t.Errorf("Unexpected traceparent headers %q", traceparents[1:])
+		}
+	})
+}
+
+func TestMetrics(t *testing.T) {
+	td := setupTestData()
+	ts := setupTestServer(td)
+	defer ts.Close()
+
+	metrics := NewMetrics(0.5, 1)
+	client := NewClient(ClientConfig{BaseURL: ts.URL, PublicKey: "test_pk", PrivateKey: "test_sk", Metrics: metrics})
+
+	client.GetAllNetwork()
+	client.GetAllNetwork()
+	client.GetCustomer("nonexistent@example.com")
+
+	rec := httptest.NewRecorder()
+	metrics.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	out := rec.Body.String()
+
+	for _, want := range []string{
+		"# TYPE longswipe_requests_total counter",
+		`longswipe_requests_total{operation="GetAllNetwork",status="200"} 2`,
+		`longswipe_requests_total{operation="GetCustomer",status="404"} 1`,
+		`longswipe_request_errors_total{operation="GetCustomer",status="404"} 1`,
+		`longswipe_request_duration_seconds_bucket{operation="GetAllNetwork",le="+Inf"} 2`,
+		`longswipe_request_duration_seconds_count{operation="GetCustomer"} 1`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected exposition to contain %s, got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, `longswipe_request_errors_total{operation="GetAllNetwork"`) {
+		t.Error("Did not expect errors for successful calls")
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Unexpected content type %q", ct)
+	}
+}
+
+func TestCassette(t *testing.T) {
+	td := setupTestData()
+	ts := setupTestServer(td)
+	defer ts.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	config := ClientConfig{PublicKey: "test_pk", PrivateKey: "test_sk", Retry: RetryPolicy{MaxAttempts: 1}}
+
+	recorder, err := NewCassette(path, ModeRecord, nil)
+	if err != nil {
+		t.Fatalf("NewCassette failed: %v", err)
+	}
+	recording, err := NewClientWithOptions(config, WithBaseURL(ts.URL), WithTransport(recorder))
+	if err != nil {
+		t.Fatalf("NewClientWithOptions failed: %v", err)
+	}
+	if _, err := recording.GetAllNetwork(); err != nil {
+		t.Fatalf("GetAllNetwork failed: %v", err)
+	}
+	redeem := &RedeemRequest{VoucherCode: td.Voucher.Code, Amount: 50, LockPin: "4321"}
+	if _, err := recording.RedeemVoucher(redeem); err != nil {
+		t.Fatalf("RedeemVoucher failed: %v", err)
+	}
+	if err := recorder.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read cassette: %v", err)
+	}
+	for _, secret := range []string{"test_pk", "test_sk", "4321"} {
+		if bytes.Contains(data, []byte(secret)) {
+			t.Errorf("Expected %q to be redacted from the cassette", secret)
+		}
+	}
+
+	player, err := NewCassette(path, ModeReplay, nil)
+	if err != nil {
+		t.Fatalf("NewCassette failed: %v", err)
+	}
+	replaying, err := NewClientWithOptions(config, WithBaseURL("https://offline.invalid"), WithTransport(player))
+	if err != nil {
+		t.Fatalf("NewClientWithOptions failed: %v", err)
+	}
+
+	networks, err := replaying.GetAllNetwork()
+	if err != nil {
+		t.Fatalf("Replayed GetAllNetwork failed: %v", err)
+	}
+	if len(networks.Data) != len(td.Networks) {
+		t.Errorf("Expected %d networks, got %d", len(td.Networks), len(networks.Data))
+	}
+	if _, err := replaying.RedeemVoucher(redeem); err != nil {
+		t.Errorf("Replayed RedeemVoucher failed: %v", err)
+	}
+
+	_, err = replaying.GetAllCurrency()
+	if !errors.Is(err, ErrNoRecordedResponse) {
+		t.Errorf("Expected ErrNoRecordedResponse for unmatched request, got %v", err)
+	}
+	var reqErr *RequestError
+	if !errors.As(err, &reqErr) || reqErr.Attempts != 1 {
+		t.Errorf("Expected the miss to be reported after 1 attempt, got %v", err)
+	}
+}
+
+func TestNewClientFromEnv(t *testing.T) {
+	setEnv := func(t *testing.T, prefix, baseURL, publicKey, privateKey, timeout string) {
+		t.Setenv(prefix+"_BASE_URL", baseURL)
+		t.Setenv(prefix+"_PUBLIC_KEY", publicKey)
+		t.Setenv(prefix+"_PRIVATE_KEY", privateKey)
+		t.Setenv(prefix+"_TIMEOUT", timeout)
+	}
+
+	t.Run("InfersProductionFromLiveKeys", func(t *testing.T) {
+		setEnv(t, "LONGSWIPE", "", "pk_live_abc", "sk_live_def", "15s")
+		client, err := NewClientFromEnv("")
+		if err != nil {
+			t.Fatalf("NewClientFromEnv failed: %v", err)
+		}
+		if client.baseURL != PRODUCTION || client.httpClient.Timeout != 15*time.Second {
+			t.Errorf("Unexpected client %s %v", client.baseURL, client.httpClient.Timeout)
+		}
+	})
+
+	t.Run("InfersSandboxWithCustomPrefix", func(t *testing.T) {
+		setEnv(t, "ACME_LS", "", "pk_test_abc", "sk_test_def", "20")
+		config, err := ConfigFromEnv("ACME_LS")
+		if err != nil {
+			t.Fatalf("ConfigFromEnv failed: %v", err)
+		}
+		if config.BaseURL != SANDBOX || config.Timeout != 20*time.Second {
+			t.Errorf("Unexpected config %+v", config)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		cases := map[string][4]string{
+			"LiveKeysOnSandbox":    {SANDBOX, "pk_live_abc", "sk_live_def", ""},
+			"TestKeysOnProduction": {PRODUCTION, "pk_test_abc", "sk_test_def", ""},
+			"MixedKeys":            {"", "pk_live_abc", "sk_test_def", ""},
+			"MissingPrivateKey":    {PRODUCTION, "pk_live_abc", "", ""},
+			"UnknownKeysNoBaseURL": {"", "abc", "def", ""},
+			"InvalidTimeout":       {PRODUCTION, "pk_live_abc", "sk_live_def", "soon"},
+		}
+		for name, env := range cases {
+			setEnv(t, "LONGSWIPE", env[0], env[1], env[2], env[3])
+			if _, err := NewClientFromEnv(""); err == nil {
+				t.Errorf("%s: expected error, got nil", name)
+			}
+		}
+	})
+}
+
+func TestCredentialRotation(t *testing.T) {
+	var (
+		mu          sync.Mutex
+		publicKeys  []string
+		privateKeys []string
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		publicKeys = append(publicKeys, strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+		privateKeys = append(privateKeys, r.Header.Get("X-API-Private-Key"))
+		mu.Unlock()
+		json.NewEncoder(w).Encode(SuccessResponse{Status: "success", Code: 200})
+	}))
+	defer ts.Close()
+
+	last := func() (string, string) {
+		mu.Lock()
+		defer mu.Unlock()
+		return publicKeys[len(publicKeys)-1], privateKeys[len(privateKeys)-1]
+	}
+
+	t.Run("SetCredentials", func(t *testing.T) {
+		client := NewClient(ClientConfig{BaseURL: ts.URL, PublicKey: "pk_old", PrivateKey: "sk_old"})
+		client.HealthCheck()
+		if pk, sk := last(); pk != "pk_old" || sk != "sk_old" {
+			t.Errorf("Expected old keys, got %s %s", pk, sk)
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				client.HealthCheck()
+			}()
+		}
+		client.SetCredentials("pk_new", "sk_new")
+		wg.Wait()
+
+		client.HealthCheck()
+		if pk, sk := last(); pk != "pk_new" || sk != "sk_new" {
+			t.Errorf("Expected rotated keys, got %s %s", pk, sk)
+		}
+	})
+
+	t.Run("NilProvider", func(t *testing.T) {
+		client := NewClient(ClientConfig{BaseURL: ts.URL, PublicKey: "pk_old", PrivateKey: "sk_old"})
+		client.SetCredentialsProvider(nil)
+		if _, err := client.HealthCheck(); err == nil {
+			t.Error("Expected an error without a credentials provider")
+		}
+	})
+
+	t.Run("FileCredentials", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "keys.json")
+		os.WriteFile(path, []byte(`{"publicKey":"pk_file_1","privateKey":"sk_file_1"}`), 0o600)
+
+		provider, err := NewFileCredentials(path, time.Nanosecond)
+		if err != nil {
+			t.Fatalf("NewFileCredentials failed: %v", err)
+		}
+		client, err := NewClientWithOptions(ClientConfig{BaseURL: ts.URL}, WithCredentialsProvider(provider))
+		if err != nil {
+			t.Fatalf("NewClientWithOptions failed: %v", err)
+		}
+
+		client.HealthCheck()
+		if pk, _ := last(); pk != "pk_file_1" {
+			t.Errorf("Expected keys from file, got %s", pk)
+		}
+
+		os.WriteFile(path, []byte(`{"publicKey":"pk_file_22","privateKey":"sk_file_22"}`), 0o600)
+		client.HealthCheck()
+		if pk, sk := last(); pk != "pk_file_22" || sk != "sk_file_22" {
+			t.Errorf("Expected reloaded keys, got %s %s", pk, sk)
+		}
+	})
+
+	t.Run("ProviderError", func(t *testing.T) {
+		t.Setenv("ROTATE_PUBLIC_KEY", "")
+		client := NewClient(ClientConfig{BaseURL: ts.URL, Credentials: EnvCredentials{Prefix: "ROTATE"}})
+		if _, err := client.HealthCheck(); err == nil {
+			t.Error("Expected error for missing credentials, got nil")
+		}
+
+		t.Setenv("ROTATE_PUBLIC_KEY", "pk_env")
+		t.Setenv("ROTATE_PRIVATE_KEY", "sk_env")
+		client.HealthCheck()
+		if pk, sk := last(); pk != "pk_env" || sk != "sk_env" {
+			t.Errorf("Expected keys from environment, got %s %s", pk, sk)
+		}
+	})
+}
+
+func TestRegistry(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		keys []string
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		keys = append(keys, r.Header.Get("X-API-Private-Key"))
+		mu.Unlock()
+		json.NewEncoder(w).Encode(SuccessResponse{Status: "success", Code: 200})
+	}))
+	defer ts.Close()
+
+	var roundTrips int32
+	metrics := NewMetrics()
+	registry := NewRegistry(
+		WithBaseURL(ts.URL),
+		WithMetrics(metrics),
+		WithTransport(roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			atomic.AddInt32(&roundTrips, 1)
+			return http.DefaultTransport.RoundTrip(r)
+		})),
+	)
+
+	a, err := registry.Register("merchant-a", ClientConfig{PublicKey: "pk_a", PrivateKey: "sk_a"})
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	b, err := registry.Register("merchant-b", ClientConfig{
+		PublicKey:  "pk_b",
+		PrivateKey: "sk_b",
+		RateLimit:  &RateLimitConfig{RateLimit: RateLimit{Rate: 100, Burst: 1}},
+	})
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	if a.limiter != nil || b.limiter == nil {
+		t.Error("Expected only merchant-b to be rate limited")
+	}
+	if got := registry.Merchants(); len(got) != 2 || got[0] != "merchant-a" || got[1] != "merchant-b" {
+		t.Errorf("Unexpected merchants %v", got)
+	}
+
+	registry.For("merchant-a").HealthCheck()
+	registry.For("merchant-b").HealthCheck()
+	registry.For("merchant-b").HealthCheck()
+
+	mu.Lock()
+	if len(keys) != 3 || keys[0] != "sk_a" || keys[1] != "sk_b" {
+		t.Errorf("Unexpected keys sent %v", keys)
+	}
+	mu.Unlock()
+	if n := atomic.LoadInt32(&roundTrips); n != 3 {
+		t.Errorf("Expected 3 round trips through the shared transport, got %d", n)
+	}
+
+	rec := httptest.NewRecorder()
+	metrics.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	out := rec.Body.String()
+	for _, want := range []string{
+		`longswipe_requests_total{merchant="merchant-a",operation="HealthCheck",status="200"} 1`,
+		`longswipe_requests_total{merchant="merchant-b",operation="HealthCheck",status="200"} 2`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected exposition to contain %s, got:\n%s", want, out)
+		}
+	}
+
+	_, err = registry.For("merchant-c").HealthCheck()
+	if !errors.Is(err, ErrUnknownMerchant) {
+		t.Errorf("Expected ErrUnknownMerchant, got %v", err)
+	}
+
+	registry.Remove("merchant-a")
+	if _, ok := registry.Lookup("merchant-a"); ok {
+		t.Error("Expected merchant-a to be removed")
+	}
+
+	if _, err := registry.Register("merchant-d", ClientConfig{}); err == nil {
+		t.Error("Expected an error for a merchant without keys")
+	}
+	if _, err := registry.Register("merchant-d", ClientConfig{PublicKey: "pk_d", PrivateKey: "sk_d", Timeout: time.Second}); err == nil {
+		t.Error("Expected an error for a per-merchant timeout")
+	}
+	unknown := registry.For("merchant-x")
+	unknown.Use(func(next Handler) Handler { return next })
+	if unknown == registry.For("merchant-x") {
+		t.Error("Expected every unknown merchant lookup to get its own client")
+	}
+	if _, err := registry.For("merchant-x").HealthCheck(); !errors.Is(err, ErrUnknownMerchant) || !strings.Contains(err.Error(), "merchant-x") {
+		t.Errorf("Expected ErrUnknownMerchant naming the merchant, got %v", err)
+	}
+
+	t.Run("MerchantOverridesShared", func(t *testing.T) {
+		other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode(SuccessResponse{Status: "success", Code: 200})
+		}))
+		defer other.Close()
+
+		registry := NewRegistry(
+			WithBaseURL(ts.URL),
+			WithRateLimit(RateLimitConfig{RateLimit: RateLimit{Rate: 1, Burst: 1}}),
+		)
+		e, err := registry.Register("merchant-e", ClientConfig{
+			BaseURL:    other.URL,
+			PublicKey:  "pk_e",
+			PrivateKey: "sk_e",
+			RateLimit:  &RateLimitConfig{RateLimit: RateLimit{Rate: 100, Burst: 5}},
+		})
+		if err != nil {
+			t.Fatalf("Register failed: %v", err)
+		}
+		if e.baseURL != other.URL {
+			t.Errorf("Expected merchant BaseURL %s, got %s", other.URL, e.baseURL)
+		}
+		if e.limiter == nil || e.limiter.config.RateLimit.Burst != 5 {
+			t.Error("Expected the merchant rate limit to override the shared one")
+		}
+	})
+}
+
+func TestResponseSizeLimit(t *testing.T) {
+	td := setupTestData()
+	invoices := td.InvoiceResp
+	for i := 1; i < 50; i++ {
+		invoices.Data.Invoices = append(invoices.Data.Invoices, td.InvoiceResp.Data.Invoices[0])
+	}
+	payload, _ := json.Marshal(invoices)
+
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.URL.Path == "/merchant-integrations/fetch-supported-cryptonetworks" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write(bytes.Repeat([]byte("x"), 4096))
+			return
+		}
+		w.Write(payload)
+	}))
+	defer ts.Close()
+
+	t.Run("StreamsWithinLimit", func(t *testing.T) {
+		var body []byte
+		client := NewClient(ClientConfig{BaseURL: ts.URL, PublicKey: "test_pk", PrivateKey: "test_sk"})
+		client.Use(func(next Handler) Handler {
+			return func(ctx context.Context, req *Request) (*Response, error) {
+				resp, err := next(ctx, req)
+				if resp != nil {
+					body = resp.Body
+				}
+				return resp, err
+			}
+		})
+
+		res, err := client.FetchInvoice(&Pagination{Page: 1, Limit: 50})
+		if err != nil {
+			t.Fatalf("FetchInvoice failed: %v", err)
+		}
+		if len(res.Data.Invoices) != 50 {
+			t.Errorf("Expected 50 invoices, got %d", len(res.Data.Invoices))
+		}
+		if body != nil {
+			t.Error("Expected the body to be decoded without buffering")
+		}
+	})
+
+	t.Run("KeepBody", func(t *testing.T) {
+		var body []byte
+		client := NewClient(ClientConfig{BaseURL: ts.URL, PublicKey: "test_pk", PrivateKey: "test_sk"})
+		client.Use(func(next Handler) Handler {
+			return func(ctx context.Context, req *Request) (*Response, error) {
+				req.KeepBody = true
+				resp, err := next(ctx, req)
+				if resp != nil {
+					body = resp.Body
+				}
+				return resp, err
+			}
+		})
+
+		res, err := client.FetchInvoice(&Pagination{Page: 1, Limit: 50})
+		if err != nil {
+			t.Fatalf("FetchInvoice failed: %v", err)
+		}
+		if len(res.Data.Invoices) != 50 {
+			t.Errorf("Expected 50 invoices, got %d", len(res.Data.Invoices))
+		}
+		if !bytes.Equal(body, payload) {
+			t.Error("Expected middleware to see the raw body")
+		}
+	})
+
+	t.Run("ExceedsLimit", func(t *testing.T) {
+		atomic.StoreInt32(&requests, 0)
+		client, err := NewClientWithOptions(ClientConfig{BaseURL: ts.URL, PublicKey: "test_pk", PrivateKey: "test_sk"},
+			WithMaxResponseSize(int64(len(payload)/2)))
+		if err != nil {
+			t.Fatalf("NewClientWithOptions failed: %v", err)
+		}
+
+		_, err = client.FetchInvoice(&Pagination{Page: 1, Limit: 50})
+		if !errors.Is(err, ErrResponseTooLarge) {
+			t.Errorf("Expected ErrResponseTooLarge, got %v", err)
+		}
+		if n := atomic.LoadInt32(&requests); n != 1 {
+			t.Errorf("Expected an oversized response not to be retried, got %d requests", n)
+		}
+
+		client, _ = NewClientWithOptions(ClientConfig{BaseURL: ts.URL, PublicKey: "test_pk", PrivateKey: "test_sk"},
+			WithMaxResponseSize(1024))
+		_, err = client.GetAllNetwork()
+		if !errors.Is(err, ErrResponseTooLarge) {
+			t.Errorf("Expected ErrResponseTooLarge for an oversized error body, got %v", err)
+		}
+		var apiErr *APIError
+		if !errors.Is(err, ErrValidation) || !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest || len(apiErr.Body) != 1024 {
+			t.Errorf("Expected an *APIError with the truncated body, got %v", err)
+		}
+	})
+
+	t.Run("Unlimited", func(t *testing.T) {
+		client := NewClient(ClientConfig{BaseURL: ts.URL, PublicKey: "test_pk", PrivateKey: "test_sk", MaxResponseSize: -1})
+		if _, err := client.FetchInvoice(&Pagination{Page: 1, Limit: 50}); err != nil {
+			t.Errorf("FetchInvoice failed: %v", err)
+		}
+	})
+
+	t.Run("PayloadLoggingKeepsBody", func(t *testing.T) {
+		var buf bytes.Buffer
+		client := NewClient(ClientConfig{
+			BaseURL:    ts.URL,
+			PublicKey:  "test_pk",
+			PrivateKey: "test_sk",
+			Logger:     slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})),
+			Log:        LogConfig{Payloads: true},
+		})
+		res, err := client.FetchInvoice(&Pagination{Page: 1, Limit: 50})
+		if err != nil || len(res.Data.Invoices) != 50 {
+			t.Fatalf("FetchInvoice failed: %v", err)
+		}
+		if !strings.Contains(buf.String(), "response_body") {
+			t.Error("Expected the response body to be logged")
+		}
+	})
+}
+
+func TestTLSOptions(t *testing.T) {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) > 0 {
+			w.Header().Set("X-Client-Cert", "present")
+		}
+		json.NewEncoder(w).Encode(SuccessResponse{Status: "success", Code: 200})
+	}))
+	ts.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	ts.Config.ErrorLog = log.New(io.Discard, "", 0)
+	ts.StartTLS()
+	defer ts.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ts.Certificate())
+	// Pins are matched on the TLS server name, which is never an IP address.
+	const host = "example.com"
+	pin := SPKIPin(ts.Certificate())
+	otherPin := base64.StdEncoding.EncodeToString(make([]byte, 32))
+
+	newClient := func(opts ...Option) *Client {
+		t.Helper()
+		client, err := NewClientWithOptions(ClientConfig{BaseURL: ts.URL, PublicKey: "test_pk", PrivateKey: "test_sk"}, opts...)
+		if err != nil {
+			t.Fatalf("NewClientWithOptions failed: %v", err)
+		}
+		return client
+	}
+
+	t.Run("RootCAs", func(t *testing.T) {
+		if _, err := newClient().HealthCheck(); err == nil {
+			t.Error("Expected the test certificate to be rejected by the system roots")
+		}
+		if _, err := newClient(WithRootCAs(roots)).HealthCheck(); err != nil {
+			t.Errorf("Expected success with custom roots, got %v", err)
+		}
+	})
+
+	t.Run("PinRotation", func(t *testing.T) {
+		pins := NewPinSet()
+		if err := pins.Set(host, "sha256/"+otherPin); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+		serverName := WithTLSConfig(&tls.Config{ServerName: host})
+		if _, err := newClient(WithRootCAs(roots), WithPins(pins), serverName).HealthCheck(); !errors.Is(err, ErrPinMismatch) {
+			t.Errorf("Expected ErrPinMismatch, got %v", err)
+		}
+
+		// Roll the new key in alongside the old one, then retire the old one.
+		pins.Set(host, otherPin, pin)
+		if _, err := newClient(WithRootCAs(roots), WithPins(pins), serverName).HealthCheck(); err != nil {
+			t.Errorf("Expected success with the rotated pin set, got %v", err)
+		}
+		pins.Set(host, pin)
+		if _, err := newClient(WithRootCAs(roots), WithPins(pins), serverName).HealthCheck(); err != nil {
+			t.Errorf("Expected success with the new pin, got %v", err)
+		}
+
+		if err := pins.Set(host, "not-a-pin"); err == nil {
+			t.Error("Expected an error for a malformed pin")
+		}
+	})
+
+	t.Run("PinOnUnverifiedCertificate", func(t *testing.T) {
+		// A server can send any certificate after its own; pinning its key
+		// must not be enough to pass.
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatalf("GenerateKey failed: %v", err)
+		}
+		template := &x509.Certificate{SerialNumber: big.NewInt(2), NotAfter: time.Now().Add(time.Hour)}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+		if err != nil {
+			t.Fatalf("CreateCertificate failed: %v", err)
+		}
+		extra, _ := x509.ParseCertificate(der)
+
+		chain := ts.TLS.Certificates[0]
+		chain.Certificate = append(append([][]byte(nil), chain.Certificate...), der)
+		decoy := httptest.NewUnstartedServer(ts.Config.Handler)
+		decoy.TLS = &tls.Config{Certificates: []tls.Certificate{chain}}
+		decoy.Config.ErrorLog = log.New(io.Discard, "", 0)
+		decoy.StartTLS()
+		defer decoy.Close()
+
+		pins := NewPinSet()
+		pins.Set(host, SPKIPin(extra))
+		for name, tlsConfig := range map[string]*tls.Config{
+			"Verified":           {ServerName: host},
+			"InsecureSkipVerify": {ServerName: host, InsecureSkipVerify: true},
+		} {
+			client, err := NewClientWithOptions(ClientConfig{BaseURL: decoy.URL, PublicKey: "test_pk", PrivateKey: "test_sk"},
+				WithRootCAs(roots), WithPins(pins), WithTLSConfig(tlsConfig))
+			if err != nil {
+				t.Fatalf("NewClientWithOptions failed: %v", err)
+			}
+			if _, err := client.HealthCheck(); !errors.Is(err, ErrPinMismatch) {
+				t.Errorf("%s: expected ErrPinMismatch, got %v", name, err)
+			}
+		}
+	})
+
+	t.Run("ClientCertificate", func(t *testing.T) {
+		var header http.Header
+		client := newClient(WithRootCAs(roots), WithClientCertificate(ts.TLS.Certificates[0]))
+		client.Use(func(next Handler) Handler {
+			return func(ctx context.Context, req *Request) (*Response, error) {
+				resp, err := next(ctx, req)
+				if resp != nil {
+					header = resp.Header
+				}
+				return resp, err
+			}
+		})
+		if _, err := client.HealthCheck(); err != nil {
+			t.Fatalf("HealthCheck failed: %v", err)
+		}
+		if header.Get("X-Client-Cert") != "present" {
+			t.Error("Expected the client certificate to be presented")
+		}
+	})
+
+	t.Run("Proxy", func(t *testing.T) {
+		var proxied string
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			proxied = r.URL.String()
+			json.NewEncoder(w).Encode(SuccessResponse{Status: "success", Code: 200})
+		}))
+		defer proxy.Close()
+
+		proxyURL, _ := url.Parse(proxy.URL)
+		client, err := NewClientWithOptions(ClientConfig{BaseURL: "http://api.longswipe.invalid", PublicKey: "test_pk", PrivateKey: "test_sk"},
+			WithProxy(proxyURL))
+		if err != nil {
+			t.Fatalf("NewClientWithOptions failed: %v", err)
+		}
+		if _, err := client.HealthCheck(); err != nil {
+			t.Fatalf("HealthCheck failed: %v", err)
+		}
+		if !strings.HasPrefix(proxied, "http://api.longswipe.invalid/") {
+			t.Errorf("Expected the request to go through the proxy, got %q", proxied)
+		}
+	})
+
+	t.Run("RejectsCustomTransport", func(t *testing.T) {
+		_, err := NewClientWithOptions(ClientConfig{BaseURL: ts.URL, PublicKey: "test_pk", PrivateKey: "test_sk"},
+			WithTransport(http.DefaultTransport), WithPins(NewPinSet()))
+		if err == nil || !strings.Contains(err.Error(), "Pins only applies to the SDK-built transport") {
+			t.Errorf("Expected a configuration error, got %v", err)
+		}
+	})
+}
+
+func TestDo(t *testing.T) {
+	type rate struct {
+		Pair string  `json:"pair"`
+		Rate float64 `json:"rate"`
+	}
+
+	var (
+		gotPath           string
+		gotKey            string
+		gotIdempotencyKey string
+		gotBody           map[string]interface{}
+		operations        []string
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.RequestURI()
+		gotKey = r.Header.Get("X-API-Private-Key")
+		gotIdempotencyKey = r.Header.Get("Idempotency-Key")
+		gotBody = nil
+		json.NewDecoder(r.Body).Decode(&gotBody)
+		if r.URL.Path == "/merchant-integrations/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{Status: "error", Code: 404, Message: "not found"})
+			return
+		}
+		json.NewEncoder(w).Encode(ApiResponse[rate]{Status: "success", Code: 200, Data: rate{Pair: "USDT/NGN", Rate: 1550.5}})
+	}))
+	defer ts.Close()
+
+	client := NewClient(ClientConfig{BaseURL: ts.URL, PublicKey: "test_pk", PrivateKey: "test_sk"})
+	client.Use(func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) (*Response, error) {
+			operations = append(operations, req.Operation)
+			return next(ctx, req)
+		}
+	})
+
+	res, err := Do[rate](context.Background(), client, GET, "merchant-integrations/exchange-rate",
+		url.Values{"from": {"USDT"}, "to": {"NGN"}}, nil)
+	if err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	if res.Data.Pair != "USDT/NGN" || res.Data.Rate != 1550.5 {
+		t.Errorf("Unexpected response %+v", res)
+	}
+	if gotPath != "/merchant-integrations/exchange-rate?from=USDT&to=NGN" {
+		t.Errorf("Unexpected request URI %s", gotPath)
+	}
+	if gotKey != "test_sk" {
+		t.Errorf("Expected auth headers, got private key %q", gotKey)
+	}
+
+	_, err = Do[rate](WithIdempotencyKey(context.Background(), "rate-1"), client, POST, "/merchant-integrations/exchange-rate?v=2",
+		url.Values{"page": {"1"}}, map[string]string{"from": "USDT"})
+	if err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	if gotPath != "/merchant-integrations/exchange-rate?v=2&page=1" || gotBody["from"] != "USDT" {
+		t.Errorf("Unexpected request %s %v", gotPath, gotBody)
+	}
+	if gotIdempotencyKey != "rate-1" {
+		t.Errorf("Expected the context key on Do, got %q", gotIdempotencyKey)
+	}
+
+	_, err = Do[rate](context.Background(), client, GET, "/merchant-integrations/missing", nil, nil)
+	var apiErr *APIError
+	if !errors.Is(err, ErrNotFound) || !errors.As(err, &apiErr) || apiErr.Message != "not found" {
+		t.Errorf("Expected a typed not found error, got %v", err)
+	}
+
+	if len(operations) != 3 || operations[0] != DoOperation {
+		t.Errorf("Expected middleware to see %s calls, got %v", DoOperation, operations)
+	}
+}
+
+func TestResponseMeta(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req-123")
+		w.Header().Set("X-RateLimit-Limit", "100")
+		w.Header().Set("X-RateLimit-Remaining", "42")
+		w.Header().Set("X-RateLimit-Reset", "30")
+		if strings.Contains(r.URL.Path, "customer") {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{Status: "error", Code: 404, Message: "customer not found"})
+			return
+		}
+		json.NewEncoder(w).Encode(SuccessResponse{Status: "success", Code: 200})
+	}))
+	defer ts.Close()
+
+	client := NewClient(ClientConfig{BaseURL: ts.URL, PublicKey: "test_pk", PrivateKey: "test_sk"})
+
+	var meta ResponseMeta
+	before := time.Now()
+	if _, err := client.HealthCheckWithContext(WithResponseMeta(context.Background(), &meta)); err != nil {
+		t.Fatalf("HealthCheck failed: %v", err)
+	}
+	if meta.StatusCode != http.StatusOK || meta.RequestID != "req-123" {
+		t.Errorf("Unexpected meta %+v", meta)
+	}
+	if meta.Date.IsZero() || meta.Latency <= 0 || meta.Header.Get("X-RateLimit-Limit") != "100" {
+		t.Errorf("Expected Date, Latency and Header to be set, got %+v", meta)
+	}
+	if !meta.HasQuota || meta.Quota.Limit != 100 || meta.Quota.Remaining != 42 {
+		t.Errorf("Unexpected quota %+v", meta.Quota)
+	}
+	if reset := meta.Quota.Reset.Sub(before); reset < 29*time.Second || reset > 31*time.Second {
+		t.Errorf("Expected reset in 30s, got %v", reset)
+	}
+
+	_, err := client.GetCustomerWithContext(WithResponseMeta(context.Background(), &meta), "nobody@example.com")
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	if meta.StatusCode != http.StatusNotFound || meta.RequestID != "req-123" {
+		t.Errorf("Expected meta for the failed call, got %+v", meta)
+	}
+
+	t.Run("UnixReset", func(t *testing.T) {
+		header := http.Header{}
+		header.Set("RateLimit-Remaining", "0")
+		header.Set("RateLimit-Reset", "1900000000")
+		q, ok := parseQuota(header, time.Now())
+		if !ok || q.Remaining != 0 || !q.Reset.Equal(time.Unix(1900000000, 0)) {
+			t.Errorf("Unexpected quota %+v", q)
+		}
+		if _, ok := parseQuota(http.Header{}, time.Now()); ok {
+			t.Error("Expected no quota without headers")
+		}
+	})
+}
+
+func TestQuota(t *testing.T) {
+	var remaining int32 = 5
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("RateLimit-Limit", "5")
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(int(atomic.AddInt32(&remaining, -1))))
+		w.Header().Set("RateLimit-Reset", "60")
+		json.NewEncoder(w).Encode(SuccessResponse{Status: "success", Code: 200})
+	}))
+	defer ts.Close()
+
+	type alert struct {
+		group string
+		quota Quota
+	}
+	var alerts []alert
+	client, err := NewClientWithOptions(ClientConfig{BaseURL: ts.URL, PublicKey: "test_pk", PrivateKey: "test_sk"},
+		WithLowQuotaCallback(2, func(group string, quota Quota) {
+			alerts = append(alerts, alert{group, quota})
+		}))
+	if err != nil {
+		t.Fatalf("NewClientWithOptions failed: %v", err)
+	}
+
+	if _, ok := client.Quota("merchant-integrations"); ok {
+		t.Error("Expected no quota before the first call")
+	}
+
+	for i := 0; i < 4; i++ {
+		client.GetAllNetwork()
+	}
+
+	quota, ok := client.Quota("merchant-integrations")
+	if !ok || quota.Limit != 5 || quota.Remaining != 1 || quota.Reset.IsZero() {
+		t.Errorf("Unexpected quota %+v", quota)
+	}
+	if quotas := client.Quotas(); len(quotas) != 1 || quotas["merchant-integrations"] != quota {
+		t.Errorf("Unexpected quotas %v", quotas)
+	}
+	if len(alerts) != 1 || alerts[0].group != "merchant-integrations" || alerts[0].quota.Remaining != 1 {
+		t.Errorf("Expected a single low quota alert, got %+v", alerts)
+	}
+
+	limitOnly := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "1000")
+		json.NewEncoder(w).Encode(SuccessResponse{Status: "success", Code: 200})
+	}))
+	defer limitOnly.Close()
+
+	alerts = nil
+	client, _ = NewClientWithOptions(ClientConfig{BaseURL: limitOnly.URL, PublicKey: "test_pk", PrivateKey: "test_sk"},
+		WithLowQuotaCallback(2, func(group string, quota Quota) {
+			alerts = append(alerts, alert{group, quota})
+		}))
+	client.GetAllNetwork()
+	if quota, ok := client.Quota("merchant-integrations"); !ok || quota.Limit != 1000 || quota.HasRemaining {
+		t.Errorf("Unexpected quota %+v", quota)
+	}
+	if len(alerts) != 0 {
+		t.Errorf("Expected no alert without a Remaining header, got %+v", alerts)
+	}
+}
+
+func TestDebugDump(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req-dump")
+		json.NewEncoder(w).Encode(SuccessResponse{Status: "success", Message: "sent to jane@example.com", Code: 200})
+	}))
+	defer ts.Close()
+
+	payout := &CustomerPayout{
+		Amount:                   10,
+		ReferenceId:              "ref-dump",
+		LongswipeUsernameOrEmail: "jane@example.com",
+		MetaData:                 "order 42",
+	}
+
+	t.Run("Full", func(t *testing.T) {
+		var buf bytes.Buffer
+		client, err := NewClientWithOptions(ClientConfig{BaseURL: ts.URL, PublicKey: "pk_secret", PrivateKey: "sk_secret"},
+			WithDebug(&buf, false))
+		if err != nil {
+			t.Fatalf("NewClientWithOptions failed: %v", err)
+		}
+		if _, err := client.RedeemVoucher(&RedeemRequest{VoucherCode: "V1", Amount: 5, LockPin: "1234"}); err != nil {
+			t.Fatalf("RedeemVoucher failed: %v", err)
+		}
+		if _, err := client.PayoutToLongSwipeUser(payout); err != nil {
+			t.Fatalf("PayoutToLongSwipeUser failed: %v", err)
+		}
+
+		out := buf.String()
+		for _, want := range []string{
+			"--- longswipe RedeemVoucher request ---",
+			"POST /merchant-integrations",
+			"--- longswipe PayoutToLongSwipeUser response ---",
+			"HTTP/1.1 200 OK",
+			"X-Request-Id: req-dump",
+			`"longswipeUsernameOrEmail":"jane@example.com"`,
+			"sent to jane@example.com",
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("Expected dump to contain %q, got:\n%s", want, out)
+			}
+		}
+		for _, secret := range []string{"pk_secret", "sk_secret", "1234"} {
+			if strings.Contains(out, secret) {
+				t.Errorf("Dump leaked %q:\n%s", secret, out)
+			}
+		}
+	})
+
+	t.Run("RedactPII", func(t *testing.T) {
+		var buf bytes.Buffer
+		client := NewClient(ClientConfig{
+			BaseURL:    ts.URL,
+			PublicKey:  "pk_secret",
+			PrivateKey: "sk_secret",
+			Debug:      &DebugConfig{Writer: &buf, RedactPII: true},
+		})
+		if _, err := client.PayoutToLongSwipeUser(payout); err != nil {
+			t.Fatalf("PayoutToLongSwipeUser failed: %v", err)
+		}
+		if _, err := client.AddCustomer(&AddNewCustomer{Name: "Jane Doe", Email: "jane@example.com"}); err != nil {
+			t.Fatalf("AddCustomer failed: %v", err)
+		}
+		client.GetCustomer("jane+test@example.com")
+
+		failing := NewClient(ClientConfig{
+			BaseURL:    ts.URL,
+			PublicKey:  "pk_secret",
+			PrivateKey: "sk_secret",
+			Retry:      RetryPolicy{MaxAttempts: 1},
+			Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+				return nil, fmt.Errorf("no route to %s", r.URL.Path)
+			}),
+			Debug: &DebugConfig{Writer: &buf, RedactPII: true},
+		})
+		failing.GetCustomer("jane@example.com")
+
+		out := buf.String()
+		if !strings.Contains(out, "GET /merchant-integrations-server/fetch-customer-by-email/[REDACTED]") {
+			t.Errorf("Expected the email in the URL to be masked, got:\n%s", out)
+		}
+		for _, pii := range []string{"example.com", "jane", "order 42", "Jane Doe"} {
+			if strings.Contains(out, pii) {
+				t.Errorf("Dump leaked %q:\n%s", pii, out)
+			}
+		}
+		if !strings.Contains(out, `"referenceId":"ref-dump"`) {
+			t.Errorf("Expected non-PII fields to be kept, got:\n%s", out)
+		}
+	})
+
+	t.Run("TransportError", func(t *testing.T) {
+		var buf bytes.Buffer
+		client := NewClient(ClientConfig{
+			BaseURL:    ts.URL,
+			PublicKey:  "pk_secret",
+			PrivateKey: "sk_secret",
+			Retry:      RetryPolicy{MaxAttempts: 1},
+			Transport: roundTripFunc(func(*http.Request) (*http.Response, error) {
+				return nil, errors.New("connection reset")
+			}),
+			Debug: &DebugConfig{Writer: &buf},
+		})
+		client.HealthCheck()
+		if out := buf.String(); !strings.Contains(out, "--- longswipe HealthCheck error ---") || !strings.Contains(out, "connection reset") {
+			t.Errorf("Expected the error to be dumped, got:\n%s", out)
+		}
+	})
+}
+
+func TestRoutes(t *testing.T) {
+	var (
+		gotMethod string
+		gotURI    string
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		gotURI = r.URL.RequestURI()
+		json.NewEncoder(w).Encode(SuccessResponse{Status: "success", Code: 200})
+	}))
+	defer ts.Close()
+
+	t.Run("Escaping", func(t *testing.T) {
+		client := NewClient(ClientConfig{BaseURL: ts.URL, PublicKey: "test_pk", PrivateKey: "test_sk"})
+
+		for _, tc := range []struct {
+			call func()
+			want string
+		}{
+			{func() { client.GetCustomer("jane+test@example.com") }, "/merchant-integrations-server/fetch-customer-by-email/jane%2Btest@example.com"},
+			{func() { client.VerifyTransaction("ref/1#2") }, "/merchant-integrations-server/verify-transaction/ref%2F1%232"},
+			{func() { client.ConfirmUser("a b") }, "/merchant-integrations/confirm-user/a%20b"},
+			{func() { client.AccountBalance("US&D") }, "/merchant-integrations/fetch-balance?currencyAbbreviation=US%26D"},
+			{func() { client.GetCustomerTransactions("c/1", "1", "10", "a&b") }, "/merchant-integrations-server/fetch-customer-transactions/c%2F1?limit=10&page=1&status=a%26b"},
+		} {
+			tc.call()
+			if gotURI != tc.want {
+				t.Errorf("Expected %s, got %s", tc.want, gotURI)
+			}
+		}
+	})
+
+	t.Run("VersionAndOverrides", func(t *testing.T) {
+		routes := map[string]Route{
+			"GetCustomer": {Method: POST, Path: "/customers/lookup/{email}"},
+		}
+		client, err := NewClientWithOptions(ClientConfig{
+			BaseURL:    ts.URL,
+			PublicKey:  "test_pk",
+			PrivateKey: "test_sk",
+			APIVersion: "v2",
+			Routes:     routes,
+		})
+		if err != nil {
+			t.Fatalf("NewClientWithOptions failed: %v", err)
+		}
+
+		client.GetCustomer("jane@example.com")
+		if gotMethod != POST || gotURI != "/v2/customers/lookup/jane@example.com" {
+			t.Errorf("Expected the overridden route, got %s %s", gotMethod, gotURI)
+		}
+		client.HealthCheck()
+		if gotURI != "/v2/merchant-integrations-server/health" {
+			t.Errorf("Expected the version prefix on default routes, got %s", gotURI)
+		}
+
+		if DefaultRoutes()["GetCustomer"].Path != "/merchant-integrations-server/fetch-customer-by-email/{email}" {
+			t.Error("Expected overrides not to change the default routes")
+		}
+	})
+
+	t.Run("InvalidOverrides", func(t *testing.T) {
+		for name, routes := range map[string]map[string]Route{
+			"unknown operation":   {"Teleport": {Method: GET, Path: "/teleport"}},
+			"missing placeholder": {"GetCustomer": {Method: GET, Path: "/customers"}},
+			"missing method":      {"HealthCheck": {Path: "/health"}},
+		} {
+			_, err := NewClientWithOptions(ClientConfig{BaseURL: ts.URL, PublicKey: "test_pk", PrivateKey: "test_sk", Routes: routes})
+			if err == nil {
+				t.Errorf("Expected an error for %s", name)
+			}
+		}
+	})
+}
+
+func TestBatch(t *testing.T) {
+	var inFlight, maxInFlight int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		ref := path.Base(r.URL.Path)
+		if strings.HasPrefix(ref, "missing") {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{Status: "error", Code: 404, Message: "transaction not found"})
+			return
+		}
+		json.NewEncoder(w).Encode(TransactionResponse{Status: "success", Code: 200, Message: ref})
+	}))
+	defer ts.Close()
+
+	client := NewClient(ClientConfig{BaseURL: ts.URL, PublicKey: "test_pk", PrivateKey: "test_sk"})
+
+	var refs []string
+	for i := 0; i < 20; i++ {
+		if i%5 == 0 {
+			refs = append(refs, fmt.Sprintf("missing-%d", i))
+		} else {
+			refs = append(refs, fmt.Sprintf("ref-%d", i))
+		}
+	}
+
+	results := Batch(context.Background(), refs, 3, client.VerifyTransactionWithContext)
+	if len(results) != len(refs) {
+		t.Fatalf("Expected %d results, got %d", len(refs), len(results))
+	}
+	for i, result := range results {
+		if result.Input != refs[i] {
+			t.Errorf("Result %d: expected input %s, got %s", i, refs[i], result.Input)
+		}
+		if i%5 == 0 {
+			if !errors.Is(result.Err, ErrNotFound) {
+				t.Errorf("Result %d: expected ErrNotFound, got %v", i, result.Err)
+			}
+			continue
+		}
+		if result.Err != nil || result.Value.Message != refs[i] {
+			t.Errorf("Result %d: unexpected %+v", i, result)
+		}
+	}
+	if max := atomic.LoadInt32(&maxInFlight); max > 3 {
+		t.Errorf("Expected at most 3 concurrent calls, got %d", max)
+	}
+
+	t.Run("Cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		calls := 0
+		results := Batch(ctx, []int{1, 2, 3}, 1, func(ctx context.Context, n int) (int, error) {
+			calls++
+			cancel()
+			return n * 2, nil
+		})
+		if calls != 1 || results[0].Value != 2 {
+			t.Errorf("Expected only the first call to run, got %d calls and %+v", calls, results[0])
+		}
+		if !errors.Is(results[1].Err, context.Canceled) || !errors.Is(results[2].Err, context.Canceled) {
+			t.Errorf("Expected the remaining items to report cancellation, got %+v", results[1:])
+		}
+	})
+}
+
+func TestCache(t *testing.T) {
+	td := setupTestData()
+	var (
+		requests    int32
+		notModified int32
+		failing     atomic.Bool
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("ETag", `"networks-v1"`)
+		if r.Header.Get("If-None-Match") == `"networks-v1"` {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		json.NewEncoder(w).Encode(td.NetworkResp)
+	}))
+	defer ts.Close()
+
+	newClient := func(config CacheConfig) *Client {
+		t.Helper()
+		atomic.StoreInt32(&requests, 0)
+		atomic.StoreInt32(&notModified, 0)
+		failing.Store(false)
+		client, err := NewClientWithOptions(ClientConfig{BaseURL: ts.URL, PublicKey: "test_pk", PrivateKey: "test_sk"}, WithCache(config))
+		if err != nil {
+			t.Fatalf("NewClientWithOptions failed: %v", err)
+		}
+		return client
+	}
+	fetch := func(client *Client) {
+		t.Helper()
+		res, err := client.GetAllNetwork()
+		if err != nil {
+			t.Fatalf("GetAllNetwork failed: %v", err)
+		}
+		if len(res.Data) != len(td.NetworkResp.Data) {
+			t.Fatalf("Expected %d networks, got %d", len(td.NetworkResp.Data), len(res.Data))
+		}
+	}
+
+	t.Run("FreshAndRevalidated", func(t *testing.T) {
+		client := newClient(CacheConfig{TTLs: map[string]time.Duration{"GetAllNetwork": 50 * time.Millisecond}})
+		var seen int
+		client.Use(func(next Handler) Handler {
+			return func(ctx context.Context, req *Request) (*Response, error) {
+				seen++
+				return next(ctx, req)
+			}
+		})
+		fetch(client)
+		fetch(client)
+		if n := atomic.LoadInt32(&requests); n != 1 {
+			t.Errorf("Expected the second call to be served from the cache, got %d requests", n)
+		}
+		if seen != 2 {
+			t.Errorf("Expected middleware to see cache hits, saw %d calls", seen)
+		}
+
+		time.Sleep(60 * time.Millisecond)
+		fetch(client)
+		if n, nm := atomic.LoadInt32(&requests), atomic.LoadInt32(&notModified); n != 2 || nm != 1 {
+			t.Errorf("Expected one conditional request answered 304, got %d requests and %d 304s", n, nm)
+		}
+
+		client.GetAllCurrency()
+		client.GetAllCurrency()
+		if n := atomic.LoadInt32(&requests); n != 4 {
+			t.Errorf("Expected uncached operations to hit the API, got %d requests", n)
+		}
+	})
+
+	t.Run("StaleWhileRevalidate", func(t *testing.T) {
+		client := newClient(CacheConfig{
+			TTLs:                 map[string]time.Duration{"GetAllNetwork": 10 * time.Millisecond},
+			StaleWhileRevalidate: time.Minute,
+		})
+		fetch(client)
+		time.Sleep(20 * time.Millisecond)
+		fetch(client)
+
+		deadline := time.Now().Add(time.Second)
+		for atomic.LoadInt32(&notModified) == 0 && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+		if nm := atomic.LoadInt32(&notModified); nm != 1 {
+			t.Errorf("Expected a background revalidation, got %d", nm)
+		}
+	})
+
+	t.Run("StaleIfError", func(t *testing.T) {
+		client := newClient(CacheConfig{
+			TTLs:         map[string]time.Duration{"GetAllNetwork": 10 * time.Millisecond},
+			StaleIfError: time.Minute,
+		})
+		fetch(client)
+		time.Sleep(20 * time.Millisecond)
+		failing.Store(true)
+		fetch(client)
+		if n := atomic.LoadInt32(&requests); n != 2 {
+			t.Errorf("Expected the failed refresh to reach the API, got %d requests", n)
+		}
+
+		client.InvalidateCache("GetAllNetwork")
+		if _, err := client.GetAllNetwork(); !errors.Is(err, ErrServer) {
+			t.Errorf("Expected ErrServer once the cache is invalidated, got %v", err)
+		}
+	})
+
+	t.Run("Invalidate", func(t *testing.T) {
+		client := newClient(CacheConfig{TTLs: map[string]time.Duration{"GetAllNetwork": time.Minute}})
+		fetch(client)
+		client.InvalidateCache()
+		fetch(client)
+		if n, nm := atomic.LoadInt32(&requests), atomic.LoadInt32(&notModified); n != 2 || nm != 0 {
+			t.Errorf("Expected an unconditional request after invalidation, got %d requests and %d 304s", n, nm)
+		}
+	})
+}
+
+func TestCoalescing(t *testing.T) {
+	var requests int32
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		select {
+		case <-release:
+		case <-r.Context().Done():
+			return
+		}
+		json.NewEncoder(w).Encode(TransactionResponse{Status: "success", Code: 200, Message: path.Base(r.URL.Path)})
+	}))
+	defer ts.Close()
+	defer close(release)
+
+	client, err := NewClientWithOptions(ClientConfig{BaseURL: ts.URL, PublicKey: "test_pk", PrivateKey: "test_sk"},
+		WithCoalescing("VerifyTransaction"))
+	if err != nil {
+		t.Fatalf("NewClientWithOptions failed: %v", err)
+	}
+
+	var seen int32
+	client.Use(func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) (*Response, error) {
+			atomic.AddInt32(&seen, 1)
+			return next(ctx, req)
+		}
+	})
+
+	waitForRequests := func(n int32) {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for atomic.LoadInt32(&requests) < n && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	waitForWaiters := func(t *testing.T, key string, n int) {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for time.Now().Before(deadline) {
+			client.flights.mu.Lock()
+			f := client.flights.flights[key]
+			joined := f != nil && f.waiters >= n
+			client.flights.mu.Unlock()
+			if joined {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+		t.Fatalf("Timed out waiting for %d callers to join %s", n, key)
+	}
+
+	t.Run("SharesRequest", func(t *testing.T) {
+		atomic.StoreInt32(&requests, 0)
+		atomic.StoreInt32(&seen, 0)
+		var wg sync.WaitGroup
+		results := make([]*TransactionResponse, 10)
+		errs := make([]error, 10)
+		for i := range results {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				results[i], errs[i] = client.VerifyTransaction("ref-shared")
+			}(i)
+		}
+
+		waitForWaiters(t, "VerifyTransaction /merchant-integrations-server/verify-transaction/ref-shared", 10)
+		release <- struct{}{}
+		wg.Wait()
+
+		if n := atomic.LoadInt32(&requests); n != 1 {
+			t.Errorf("Expected one HTTP request, got %d", n)
+		}
+		if n := atomic.LoadInt32(&seen); n != 10 {
+			t.Errorf("Expected middleware to see every caller, saw %d calls", n)
+		}
+		for i := range results {
+			if errs[i] != nil || results[i].Message != "ref-shared" {
+				t.Errorf("Caller %d: unexpected %+v, %v", i, results[i], errs[i])
+			}
+		}
+	})
+
+	t.Run("HonoursCallerContext", func(t *testing.T) {
+		atomic.StoreInt32(&requests, 0)
+		ctx, cancel := context.WithCancel(context.Background())
+		impatient := make(chan error, 1)
+		go func() {
+			_, err := client.VerifyTransactionWithContext(ctx, "ref-ctx")
+			impatient <- err
+		}()
+		waitForRequests(1)
+
+		patient := make(chan error, 1)
+		go func() {
+			_, err := client.VerifyTransaction("ref-ctx")
+			patient <- err
+		}()
+		waitForWaiters(t, "VerifyTransaction /merchant-integrations-server/verify-transaction/ref-ctx", 2)
+
+		cancel()
+		if err := <-impatient; !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected the cancelled caller to return context.Canceled, got %v", err)
+		}
+		release <- struct{}{}
+		if err := <-patient; err != nil {
+			t.Errorf("Expected the other caller to get the shared result, got %v", err)
+		}
+		if n := atomic.LoadInt32(&requests); n != 1 {
+			t.Errorf("Expected one HTTP request, got %d", n)
+		}
+	})
+
+	t.Run("OnlyConfiguredOperations", func(t *testing.T) {
+		atomic.StoreInt32(&requests, 0)
+		done := make(chan struct{})
+		for i := 0; i < 2; i++ {
+			go func() {
+				client.ConfirmUser("someone")
+				done <- struct{}{}
+			}()
+		}
+		waitForRequests(2)
+		if n := atomic.LoadInt32(&requests); n != 2 {
+			t.Errorf("Expected ConfirmUser calls not to be coalesced, got %d requests", n)
+		}
+		release <- struct{}{}
+		release <- struct{}{}
+		<-done
+		<-done
+	})
+}
+
 // Mock data generators
 func generateMockInvoiceCreateRequest() *CreateInvoiceRequest {
 	return &CreateInvoiceRequest{
@@ -0,0 +1,98 @@
+package longswipe
+
+import (
+	"bytes"
+	"context"
+	"sync"
+)
+
+// flightGroup collapses concurrent identical GET calls into a single request
+// whose result is shared by every caller.
+type flightGroup struct {
+	operations map[string]bool
+
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+type flight struct {
+	done    chan struct{}
+	cancel  context.CancelFunc
+	waiters int
+	resp    *Response
+	err     error
+}
+
+func newFlightGroup(operations []string) *flightGroup {
+	if len(operations) == 0 {
+		return nil
+	}
+	g := &flightGroup{
+		operations: make(map[string]bool, len(operations)),
+		flights:    make(map[string]*flight),
+	}
+	for _, operation := range operations {
+		g.operations[operation] = true
+	}
+	return g
+}
+
+// coalesce wraps next so that identical calls share one in-flight request.
+func (g *flightGroup) coalesce(next Handler) Handler {
+	return func(ctx context.Context, req *Request) (*Response, error) {
+		if !g.operations[req.Operation] || req.Method != GET {
+			return next(ctx, req)
+		}
+		// The shared body is decoded by each caller.
+		req.into = nil
+		key := req.Operation + " " + req.Path
+
+		g.mu.Lock()
+		f := g.flights[key]
+		if f == nil {
+			// The shared request outlives any single caller and is only
+			// cancelled once every caller has given up on it.
+			flightCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+			f = &flight{done: make(chan struct{}), cancel: cancel}
+			g.flights[key] = f
+			go g.run(flightCtx, key, f, req, next)
+		}
+		f.waiters++
+		g.mu.Unlock()
+
+		select {
+		case <-f.done:
+			if f.resp == nil {
+				return nil, f.err
+			}
+			return &Response{
+				StatusCode: f.resp.StatusCode,
+				Header:     f.resp.Header.Clone(),
+				Body:       bytes.Clone(f.resp.Body),
+			}, f.err
+		case <-ctx.Done():
+			g.mu.Lock()
+			f.waiters--
+			if f.waiters == 0 {
+				f.cancel()
+				if g.flights[key] == f {
+					delete(g.flights, key)
+				}
+			}
+			g.mu.Unlock()
+			return nil, &RequestError{Method: req.Method, Endpoint: req.Path, Err: ctx.Err()}
+		}
+	}
+}
+
+func (g *flightGroup) run(ctx context.Context, key string, f *flight, req *Request, next Handler) {
+	defer f.cancel()
+	f.resp, f.err = next(ctx, req)
+
+	g.mu.Lock()
+	if g.flights[key] == f {
+		delete(g.flights, key)
+	}
+	g.mu.Unlock()
+	close(f.done)
+}
@@ -0,0 +1,174 @@
+package longswipe
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Credentials is a LongSwipe API key pair.
+type Credentials struct {
+	PublicKey  string `json:"publicKey"`
+	PrivateKey string `json:"privateKey"`
+}
+
+// CredentialsProvider supplies the API keys for every request. It is called
+// once per attempt and must be safe for concurrent use.
+type CredentialsProvider interface {
+	Credentials(ctx context.Context) (Credentials, error)
+}
+
+// StaticCredentials is a CredentialsProvider holding a key pair that can be
+// replaced atomically with Set.
+type StaticCredentials struct {
+	creds atomic.Pointer[Credentials]
+}
+
+// NewStaticCredentials returns a provider for the given key pair.
+func NewStaticCredentials(publicKey, privateKey string) *StaticCredentials {
+	s := &StaticCredentials{}
+	s.Set(publicKey, privateKey)
+	return s
+}
+
+// Set replaces the key pair. Requests already in flight keep the keys they
+// started with.
+func (s *StaticCredentials) Set(publicKey, privateKey string) {
+	s.creds.Store(&Credentials{PublicKey: publicKey, PrivateKey: privateKey})
+}
+
+func (s *StaticCredentials) Credentials(ctx context.Context) (Credentials, error) {
+	return *s.creds.Load(), nil
+}
+
+// EnvCredentials reads <Prefix>_PUBLIC_KEY and <Prefix>_PRIVATE_KEY on every
+// request. Prefix defaults to DefaultEnvPrefix.
+type EnvCredentials struct {
+	Prefix string
+}
+
+func (e EnvCredentials) Credentials(ctx context.Context) (Credentials, error) {
+	prefix := e.Prefix
+	if prefix == "" {
+		prefix = DefaultEnvPrefix
+	}
+	prefix = strings.TrimSuffix(prefix, "_") + "_"
+
+	creds := Credentials{
+		PublicKey:  os.Getenv(prefix + EnvPublicKey),
+		PrivateKey: os.Getenv(prefix + EnvPrivateKey),
+	}
+	if creds.PublicKey == "" || creds.PrivateKey == "" {
+		return Credentials{}, fmt.Errorf("longswipe: %s%s and %s%s must be set", prefix, EnvPublicKey, prefix, EnvPrivateKey)
+	}
+	return creds, nil
+}
+
+// FileCredentials reads a key pair from a JSON file of the form
+// {"publicKey": "...", "privateKey": "..."} and reloads it whenever the file
+// changes, e.g. when a secrets manager rotates it.
+type FileCredentials struct {
+	path string
+	// interval is the minimum time between checks of the file.
+	interval time.Duration
+
+	mu      sync.Mutex
+	creds   Credentials
+	modTime time.Time
+	size    int64
+	checked time.Time
+}
+
+// NewFileCredentials loads the key pair from path. The file is checked for
+// changes at most once per interval, or every second if interval is zero.
+func NewFileCredentials(path string, interval time.Duration) (*FileCredentials, error) {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	f := &FileCredentials{path: path, interval: interval}
+	if err := f.reload(time.Now()); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *FileCredentials) Credentials(ctx context.Context) (Credentials, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(f.checked) >= f.interval {
+		// Keep serving the last good key pair if the file is briefly
+		// missing or half-written during rotation.
+		if err := f.reload(now); err != nil && f.creds == (Credentials{}) {
+			return Credentials{}, err
+		}
+	}
+	return f.creds, nil
+}
+
+// reload must be called with f.mu held, except from the constructor.
+func (f *FileCredentials) reload(now time.Time) error {
+	f.checked = now
+
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return fmt.Errorf("failed to read credentials file: %w", err)
+	}
+	if info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return nil
+	}
+
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return fmt.Errorf("failed to read credentials file: %w", err)
+	}
+	var creds Credentials
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return fmt.Errorf("failed to decode credentials file %s: %w", f.path, err)
+	}
+	if creds.PublicKey == "" || creds.PrivateKey == "" {
+		return errors.New("longswipe: credentials file must contain publicKey and privateKey")
+	}
+
+	f.creds = creds
+	f.modTime = info.ModTime()
+	f.size = info.Size()
+	return nil
+}
+
+// SetCredentials atomically replaces the client's API keys. It is safe to call
+// while requests are in flight; subsequent attempts use the new keys.
+func (c *Client) SetCredentials(publicKey, privateKey string) {
+	c.SetCredentialsProvider(NewStaticCredentials(publicKey, privateKey))
+}
+
+// SetCredentialsProvider atomically replaces the client's credentials
+// provider. With a nil provider every request fails until a provider is set.
+func (c *Client) SetCredentialsProvider(provider CredentialsProvider) {
+	if provider == nil {
+		provider = noCredentials{}
+	}
+	c.credentials.Store(&provider)
+}
+
+// noCredentials stands in for a nil CredentialsProvider.
+type noCredentials struct{}
+
+func (noCredentials) Credentials(ctx context.Context) (Credentials, error) {
+	return Credentials{}, errors.New("longswipe: no credentials provider set")
+}
+
+func (c *Client) loadCredentials(ctx context.Context) (Credentials, error) {
+	creds, err := (*c.credentials.Load()).Credentials(ctx)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("failed to load credentials: %w", err)
+	}
+	return creds, nil
+}
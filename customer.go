@@ -1,7 +1,7 @@
 package longswipe
 
 import (
-	"fmt"
+	"context"
 	"net/url"
 	"strconv"
 
@@ -9,11 +9,17 @@ import (
 )
 
 func (c *Client) GetCustomers(body *Pagination) (*CustomersResponse, error) {
-	endpoint := buildCustomerEndpoint(body.Page, body.Limit, body.Search)
+	return c.GetCustomersWithContext(context.Background(), body)
+}
+
+func (c *Client) GetCustomersWithContext(ctx context.Context, body *Pagination) (*CustomersResponse, error) {
+	method, endpoint := c.endpoint("GetCustomers", nil, customersQuery(body.Page, body.Limit, body.Search))
 	var customers CustomersResponse
 
 	_, err := c.doRequestAndUnmarshal(
-		GET,
+		ctx,
+		"GetCustomers",
+		method,
 		endpoint,
 		nil,
 		&customers,
@@ -25,23 +31,28 @@ func (c *Client) GetCustomers(body *Pagination) (*CustomersResponse, error) {
 	return &customers, nil
 }
 
-func buildCustomerEndpoint(page int, limit int, search string) string {
+func customersQuery(page int, limit int, search string) url.Values {
 	params := url.Values{}
 	params.Add("page", strconv.Itoa(page))
 	params.Add("limit", strconv.Itoa(limit))
 	params.Add("search", search)
 
-	return "/merchant-integrations-server/fetch-customers?" + params.Encode()
+	return params
 }
 
 func (c *Client) GetCustomer(email string) (*CustomerResponse, error) {
-	endpoint := fmt.Sprintf("/merchant-integrations-server/fetch-customer-by-email/%s", email)
-	fmt.Println(endpoint)
+	return c.GetCustomerWithContext(context.Background(), email)
+}
+
+func (c *Client) GetCustomerWithContext(ctx context.Context, email string) (*CustomerResponse, error) {
+	method, endpoint := c.endpoint("GetCustomer", map[string]string{"email": email}, nil)
 
 	var customer CustomerResponse
 
 	_, err := c.doRequestAndUnmarshal(
-		GET,
+		ctx,
+		"GetCustomer",
+		method,
 		endpoint,
 		nil,
 		&customer,
@@ -54,11 +65,17 @@ func (c *Client) GetCustomer(email string) (*CustomerResponse, error) {
 }
 
 func (c *Client) AddCustomer(body *AddNewCustomer) (*SuccessResponse, error) {
-	endpoint := "/merchant-integrations-server/add-new-customer"
+	return c.AddCustomerWithContext(context.Background(), body)
+}
+
+func (c *Client) AddCustomerWithContext(ctx context.Context, body *AddNewCustomer) (*SuccessResponse, error) {
+	method, endpoint := c.endpoint("AddCustomer", nil, nil)
 	var res SuccessResponse
 
 	_, err := c.doRequestAndUnmarshal(
-		POST,
+		ctx,
+		"AddCustomer",
+		method,
 		endpoint,
 		body,
 		&res,
@@ -71,11 +88,17 @@ func (c *Client) AddCustomer(body *AddNewCustomer) (*SuccessResponse, error) {
 }
 
 func (c *Client) UpdateCustomer(body *UpdatCustomer) (*SuccessResponse, error) {
-	endpoint := "/merchant-integrations-server/update-customer"
+	return c.UpdateCustomerWithContext(context.Background(), body)
+}
+
+func (c *Client) UpdateCustomerWithContext(ctx context.Context, body *UpdatCustomer) (*SuccessResponse, error) {
+	method, endpoint := c.endpoint("UpdateCustomer", nil, nil)
 	var res SuccessResponse
 
 	_, err := c.doRequestAndUnmarshal(
-		PATCH,
+		ctx,
+		"UpdateCustomer",
+		method,
 		endpoint,
 		body,
 		&res,
@@ -88,11 +111,17 @@ func (c *Client) UpdateCustomer(body *UpdatCustomer) (*SuccessResponse, error) {
 }
 
 func (c *Client) DeleteCustomer(customerID uuid.UUID) (*SuccessResponse, error) {
-	endpoint := fmt.Sprintf("/merchant-integrations-server/delete-customer/%s", customerID)
+	return c.DeleteCustomerWithContext(context.Background(), customerID)
+}
+
+func (c *Client) DeleteCustomerWithContext(ctx context.Context, customerID uuid.UUID) (*SuccessResponse, error) {
+	method, endpoint := c.endpoint("DeleteCustomer", map[string]string{"customerId": customerID.String()}, nil)
 	var res SuccessResponse
 
 	_, err := c.doRequestAndUnmarshal(
-		DELETE,
+		ctx,
+		"DeleteCustomer",
+		method,
 		endpoint,
 		nil,
 		&res,
@@ -105,11 +134,21 @@ func (c *Client) DeleteCustomer(customerID uuid.UUID) (*SuccessResponse, error)
 }
 
 func (c *Client) GetCustomerTransactions(customerID string, page, limit, status string) (*TransactionListResponse, error) {
-	endpoint := fmt.Sprintf("/merchant-integrations-server/fetch-customer-transactions/%s?page=%s&limit=%s&status=%s", customerID, page, limit, status)
+	return c.GetCustomerTransactionsWithContext(context.Background(), customerID, page, limit, status)
+}
+
+func (c *Client) GetCustomerTransactionsWithContext(ctx context.Context, customerID string, page, limit, status string) (*TransactionListResponse, error) {
+	method, endpoint := c.endpoint("GetCustomerTransactions", map[string]string{"customerId": customerID}, url.Values{
+		"page":   {page},
+		"limit":  {limit},
+		"status": {status},
+	})
 	var transactions TransactionListResponse
 
 	_, err := c.doRequestAndUnmarshal(
-		GET,
+		ctx,
+		"GetCustomerTransactions",
+		method,
 		endpoint,
 		nil,
 		&transactions,
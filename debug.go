@@ -0,0 +1,111 @@
+package longswipe
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+	"sync"
+)
+
+// DebugConfig enables dumping every HTTP request and response, as
+// httputil.DumpRequestOut and httputil.DumpResponse format them. Credentials,
+// bearer tokens and lock PINs are always redacted.
+type DebugConfig struct {
+	// Writer receives the dumps.
+	Writer io.Writer
+	// RedactPII also redacts customer names, email addresses, user
+	// identifiers and metadata in CustomerPayout, AddNewCustomer and
+	// PaymentRequest bodies, and masks email addresses everywhere else,
+	// including request URLs, headers and errors.
+	RedactPII bool
+}
+
+type debugDumper struct {
+	config DebugConfig
+	mu     sync.Mutex
+}
+
+func newDebugDumper(config *DebugConfig) *debugDumper {
+	if config == nil || config.Writer == nil {
+		return nil
+	}
+	return &debugDumper{config: *config}
+}
+
+// dump writes one attempt of call: the request as sent, then the response if
+// one was received or the error otherwise.
+func (d *debugDumper) dump(call *Request, req *http.Request, reqBody []byte, resp *http.Response, respBody []byte, err error) {
+	masking := MaskEmailsNone
+	var fields map[string]bool
+	if d.config.RedactPII {
+		masking = MaskEmailsFull
+		fields = piiFields(call.Body)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "--- longswipe %s request ---\n", call.Operation)
+	out := req.Clone(context.Background())
+	out.Header = redactHeader(req.Header)
+	if d.config.RedactPII {
+		// Mask both forms of the path so escaped addresses are caught too.
+		out.URL.RawPath = masking.mask(out.URL.EscapedPath())
+		out.URL.Path = masking.mask(out.URL.Path)
+		query := out.URL.Query()
+		for _, values := range query {
+			for i := range values {
+				values[i] = masking.mask(values[i])
+			}
+		}
+		out.URL.RawQuery = query.Encode()
+	}
+	out.Body = http.NoBody
+	out.ContentLength = 0
+	if body := redactBody(reqBody, masking, fields); body != "" {
+		out.Body = io.NopCloser(strings.NewReader(body))
+		out.ContentLength = int64(len(body))
+	}
+	if dump, dumpErr := httputil.DumpRequestOut(out, true); dumpErr != nil {
+		fmt.Fprintf(&buf, "failed to dump request: %v\n", dumpErr)
+	} else {
+		buf.Write(dump)
+	}
+
+	if resp != nil {
+		fmt.Fprintf(&buf, "\n--- longswipe %s response ---\n", call.Operation)
+		head := *resp
+		head.Body = http.NoBody
+		if dump, dumpErr := httputil.DumpResponse(&head, false); dumpErr != nil {
+			fmt.Fprintf(&buf, "failed to dump response: %v\n", dumpErr)
+		} else {
+			buf.Write(dump)
+		}
+		buf.WriteString(redactBody(respBody, masking, nil))
+		buf.WriteByte('\n')
+	}
+	if err != nil {
+		fmt.Fprintf(&buf, "\n--- longswipe %s error ---\n%v\n", call.Operation, err)
+	}
+	buf.WriteByte('\n')
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	io.WriteString(d.config.Writer, masking.mask(buf.String()))
+}
+
+// piiFields returns the personal data fields of a request body, in the lower
+// case form matched by redactBody.
+func piiFields(body interface{}) map[string]bool {
+	switch body.(type) {
+	case *CustomerPayout:
+		return map[string]bool{"longswipeusernameoremail": true, "metadata": true}
+	case *AddNewCustomer:
+		return map[string]bool{"name": true, "email": true}
+	case *PaymentRequest:
+		return map[string]bool{"user_identifier": true, "metadata": true}
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package longswipe
+
+import (
+	"context"
+	"net/url"
+	"strings"
+)
+
+// DoOperation is the operation name reported to middleware, logs, spans and
+// metrics for calls made with Do.
+const DoOperation = "Do"
+
+// Do calls an endpoint the SDK does not wrap yet and decodes the response
+// into an ApiResponse[T]. path is relative to the client's base URL and API
+// version prefix and must already be escaped; query, if non-empty, is
+// appended to it; body, if non-nil, is sent as JSON. The call goes through
+// the same authentication, retries, middleware, rate limiting, error typing
+// and hooks as the built-in methods, so failures can be inspected with
+// errors.Is and errors.As. As elsewhere, requests other than GET, HEAD and
+// OPTIONS are only retried when ctx carries an idempotency key set with
+// WithIdempotencyKey; the key is sent by every Do call made with ctx.
+func Do[T any](ctx context.Context, c *Client, method, path string, query url.Values, body interface{}) (*ApiResponse[T], error) {
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	if len(query) > 0 {
+		sep := "?"
+		if strings.Contains(path, "?") {
+			sep = "&"
+		}
+		path += sep + query.Encode()
+	}
+
+	if key := idempotencyKeyFromContext(ctx); key != "" {
+		ctx = withCallIdempotencyKey(ctx, key)
+	}
+
+	var res ApiResponse[T]
+	if _, err := c.doRequestAndUnmarshal(ctx, DoOperation, method, path, body, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
@@ -0,0 +1,149 @@
+package longswipe
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultEnvPrefix is the environment variable prefix used by
+// NewClientFromEnv when none is given.
+const DefaultEnvPrefix = "LONGSWIPE"
+
+// Environment variable suffixes read by ConfigFromEnv.
+const (
+	EnvBaseURL    = "BASE_URL"
+	EnvPublicKey  = "PUBLIC_KEY"
+	EnvPrivateKey = "PRIVATE_KEY"
+	EnvTimeout    = "TIMEOUT"
+)
+
+type keyMode int
+
+const (
+	keyModeUnknown keyMode = iota
+	keyModeLive
+	keyModeTest
+)
+
+func (m keyMode) String() string {
+	switch m {
+	case keyModeLive:
+		return "live"
+	case keyModeTest:
+		return "test"
+	}
+	return "unknown"
+}
+
+func detectKeyMode(key, kind string) keyMode {
+	switch {
+	case strings.HasPrefix(key, kind+"_live_"):
+		return keyModeLive
+	case strings.HasPrefix(key, kind+"_test_"):
+		return keyModeTest
+	}
+	return keyModeUnknown
+}
+
+// ConfigFromEnv builds a ClientConfig from the environment variables
+// <prefix>_BASE_URL, <prefix>_PUBLIC_KEY, <prefix>_PRIVATE_KEY and
+// <prefix>_TIMEOUT. The timeout is a Go duration ("15s") or a number of
+// seconds. When the base URL is unset it is inferred from the key prefixes:
+// pk_live_/sk_live_ keys select PRODUCTION and pk_test_/sk_test_ keys select
+// SANDBOX.
+func ConfigFromEnv(prefix string) (ClientConfig, error) {
+	if prefix == "" {
+		prefix = DefaultEnvPrefix
+	}
+	name := func(suffix string) string {
+		return strings.TrimSuffix(prefix, "_") + "_" + suffix
+	}
+
+	config := ClientConfig{
+		BaseURL:    os.Getenv(name(EnvBaseURL)),
+		PublicKey:  os.Getenv(name(EnvPublicKey)),
+		PrivateKey: os.Getenv(name(EnvPrivateKey)),
+	}
+
+	if config.PublicKey == "" {
+		return config, fmt.Errorf("longswipe: %s is not set", name(EnvPublicKey))
+	}
+	if config.PrivateKey == "" {
+		return config, fmt.Errorf("longswipe: %s is not set", name(EnvPrivateKey))
+	}
+
+	if raw := os.Getenv(name(EnvTimeout)); raw != "" {
+		timeout, err := time.ParseDuration(raw)
+		if err != nil {
+			seconds, convErr := strconv.Atoi(raw)
+			if convErr != nil {
+				return config, fmt.Errorf("longswipe: invalid %s %q: %w", name(EnvTimeout), raw, err)
+			}
+			timeout = time.Duration(seconds) * time.Second
+		}
+		config.Timeout = timeout
+	}
+
+	if config.BaseURL == "" {
+		switch mode, err := config.keyMode(); {
+		case err != nil:
+			return config, err
+		case mode == keyModeLive:
+			config.BaseURL = PRODUCTION
+		case mode == keyModeTest:
+			config.BaseURL = SANDBOX
+		default:
+			return config, fmt.Errorf("longswipe: %s is not set and cannot be inferred from the API keys", name(EnvBaseURL))
+		}
+	}
+
+	if err := config.checkKeyMode(); err != nil {
+		return config, err
+	}
+	return config, nil
+}
+
+// NewClientFromEnv builds a client from ConfigFromEnv(prefix) with opts
+// applied on top.
+func NewClientFromEnv(prefix string, opts ...Option) (*Client, error) {
+	config, err := ConfigFromEnv(prefix)
+	if err != nil {
+		return nil, err
+	}
+	return NewClientWithOptions(config, opts...)
+}
+
+// keyMode returns whether the configured keys are live or test keys. It
+// fails when the public and private keys disagree.
+func (c ClientConfig) keyMode() (keyMode, error) {
+	public := detectKeyMode(c.PublicKey, "pk")
+	private := detectKeyMode(c.PrivateKey, "sk")
+	if public != keyModeUnknown && private != keyModeUnknown && public != private {
+		return keyModeUnknown, fmt.Errorf("longswipe: public key is a %s key but private key is a %s key", public, private)
+	}
+	if public != keyModeUnknown {
+		return public, nil
+	}
+	return private, nil
+}
+
+// checkKeyMode fails when live keys point at the sandbox or test keys point at
+// production. Custom base URLs are not checked.
+func (c ClientConfig) checkKeyMode() error {
+	mode, err := c.keyMode()
+	if err != nil {
+		return err
+	}
+
+	baseURL := strings.TrimSuffix(c.BaseURL, "/")
+	switch {
+	case mode == keyModeLive && baseURL == SANDBOX:
+		return fmt.Errorf("longswipe: live API keys cannot be used with the sandbox (%s)", SANDBOX)
+	case mode == keyModeTest && baseURL == PRODUCTION:
+		return fmt.Errorf("longswipe: test API keys cannot be used with production (%s)", PRODUCTION)
+	}
+	return nil
+}
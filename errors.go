@@ -0,0 +1,99 @@
+package longswipe
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Sentinel errors matched by *APIError through errors.Is.
+var (
+	ErrNotFound     = errors.New("longswipe: not found")
+	ErrUnauthorized = errors.New("longswipe: unauthorized")
+	ErrRateLimited  = errors.New("longswipe: rate limited")
+	ErrValidation   = errors.New("longswipe: validation failed")
+	ErrServer       = errors.New("longswipe: server error")
+)
+
+// ErrResponseTooLarge is returned when a response body exceeds the client's
+// MaxResponseSize. For an error status it is joined with the *APIError, whose
+// Body is then truncated to MaxResponseSize.
+var ErrResponseTooLarge = errors.New("longswipe: response body too large")
+
+// APIError is returned when the LongSwipe API responds with a 4xx or 5xx
+// status. The envelope fields of the response body are decoded into the
+// embedded ErrorResponse when present.
+type APIError struct {
+	ErrorResponse
+	StatusCode int
+	Body       []byte
+	RequestID  string
+	Method     string
+	Endpoint   string
+}
+
+func newAPIError(method, endpoint string, status int, header http.Header, body []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode: status,
+		Body:       body,
+		RequestID:  header.Get("X-Request-Id"),
+		Method:     method,
+		Endpoint:   endpoint,
+	}
+	// The body is not guaranteed to be a JSON envelope (e.g. proxy errors), in
+	// which case only the raw body is kept.
+	_ = json.Unmarshal(body, &apiErr.ErrorResponse)
+	return apiErr
+}
+
+func (e *APIError) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	if len(e.Body) > 0 {
+		return string(e.Body)
+	}
+	return fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// Is reports whether the error matches one of the package sentinel errors
+// based on its HTTP status code.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrValidation:
+		return e.StatusCode == http.StatusBadRequest || e.StatusCode == http.StatusUnprocessableEntity
+	case ErrServer:
+		return e.StatusCode >= 500
+	}
+	return false
+}
+
+// RequestError is returned when a call to the LongSwipe API fails. It wraps
+// the underlying cause, which is an *APIError when the API responded with an
+// error status, and records how many attempts were made before the client
+// gave up together with the idempotency key sent, if any.
+type RequestError struct {
+	Method         string
+	Endpoint       string
+	Attempts       int
+	IdempotencyKey string
+	Err            error
+}
+
+func (e *RequestError) Error() string {
+	if e.Attempts > 1 {
+		return fmt.Sprintf("%v (after %d attempts)", e.Err, e.Attempts)
+	}
+	return e.Err.Error()
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
@@ -1,11 +1,19 @@
 package longswipe
 
+import "context"
+
 func (c *Client) GetAllNetwork() (*CryptoNetworkResponse, error) {
-	endpoint := "/merchant-integrations/fetch-supported-cryptonetworks"
+	return c.GetAllNetworkWithContext(context.Background())
+}
+
+func (c *Client) GetAllNetworkWithContext(ctx context.Context) (*CryptoNetworkResponse, error) {
+	method, endpoint := c.endpoint("GetAllNetwork", nil, nil)
 	var networks CryptoNetworkResponse
 
 	_, err := c.doRequestAndUnmarshal(
-		GET,
+		ctx,
+		"GetAllNetwork",
+		method,
 		endpoint,
 		nil,
 		&networks,
@@ -18,10 +26,16 @@ func (c *Client) GetAllNetwork() (*CryptoNetworkResponse, error) {
 }
 
 func (c *Client) GetAllCurrency() (*FetchCurrenciesResponse, error) {
-	endpoint := "/merchant-integrations/fetch-supported-currencies"
+	return c.GetAllCurrencyWithContext(context.Background())
+}
+
+func (c *Client) GetAllCurrencyWithContext(ctx context.Context) (*FetchCurrenciesResponse, error) {
+	method, endpoint := c.endpoint("GetAllCurrency", nil, nil)
 	var currencies FetchCurrenciesResponse
 	_, err := c.doRequestAndUnmarshal(
-		GET,
+		ctx,
+		"GetAllCurrency",
+		method,
 		endpoint,
 		nil,
 		&currencies,
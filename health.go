@@ -1,5 +1,7 @@
 package longswipe
 
+import "context"
+
 type HealthCheckResponse struct {
 	Status  string `json:"status"`
 	Message string `json:"message,omitempty"`
@@ -7,11 +9,18 @@ type HealthCheckResponse struct {
 }
 
 func (c *Client) HealthCheck() (*HealthCheckResponse, error) {
+	return c.HealthCheckWithContext(context.Background())
+}
+
+func (c *Client) HealthCheckWithContext(ctx context.Context) (*HealthCheckResponse, error) {
+	method, endpoint := c.endpoint("HealthCheck", nil, nil)
 	var response HealthCheckResponse
 
 	_, err := c.doRequestAndUnmarshal(
-		GET,
-		"/merchant-integrations-server/health",
+		ctx,
+		"HealthCheck",
+		method,
+		endpoint,
 		nil,
 		&response,
 	)
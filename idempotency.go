@@ -0,0 +1,53 @@
+package longswipe
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gofrs/uuid"
+)
+
+type (
+	idempotencyKeyCtxKey     struct{}
+	callIdempotencyKeyCtxKey struct{}
+)
+
+// WithIdempotencyKey returns a copy of ctx that makes Do calls made with it
+// send key in the Idempotency-Key header, so that they are retried safely.
+// Every Do call made with the returned context sends the same key, including
+// the calls of a Batch, so derive a new context for each distinct call. The
+// money-movement methods ignore it and take their key from the request's
+// IdempotencyKey field instead.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyCtxKey{}, key)
+}
+
+func idempotencyKeyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKeyCtxKey{}).(string)
+	return key
+}
+
+// withCallIdempotencyKey returns ctx making the next doRequest call send key.
+// doRequest clears it, so calls made with ctx by middleware do not inherit it.
+func withCallIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, callIdempotencyKeyCtxKey{}, key)
+}
+
+func callIdempotencyKey(ctx context.Context) string {
+	key, _ := ctx.Value(callIdempotencyKeyCtxKey{}).(string)
+	return key
+}
+
+// ensureIdempotencyKey returns ctx carrying the idempotency key of a single
+// money-movement call: key, or a random one when the caller did not supply
+// one.
+func ensureIdempotencyKey(ctx context.Context, key string) (context.Context, string, error) {
+	if key == "" {
+		id, err := uuid.NewV4()
+		if err != nil {
+			return ctx, "", fmt.Errorf("failed to generate idempotency key: %w", err)
+		}
+		key = id.String()
+	}
+	return withCallIdempotencyKey(ctx, key), key, nil
+}
@@ -13,9 +13,10 @@ type TransactionType string
 type TransactionStatus string
 
 type SuccessResponse struct {
-	Status  string `json:"status"`
-	Message string `json:"message,omitempty"`
-	Code    int    `json:"code"`
+	Status         string `json:"status"`
+	Message        string `json:"message,omitempty"`
+	Code           int    `json:"code"`
+	IdempotencyKey string `json:"-"`
 }
 
 type ApiResponse[T any] struct {
@@ -33,6 +34,7 @@ type RedeemRequest struct {
 	ToCurrencyAbbreviation string            `json:"toCurrencyAbbreviation" validate:"omitempty"`
 	ReferenceId            string            `json:"referenceId" validate:"omitempty"`
 	MetaData               map[string]string `json:"metaData" validate:"omitempty"`
+	IdempotencyKey         string            `json:"-"`
 }
 
 type UserResponse struct {
@@ -262,8 +264,9 @@ type InvoiceItemRequest struct {
 }
 
 type ApproveInvoiceRequest struct {
-	InvoiceID uuid.UUID `json:"invoiceID" validate:"required"`
-	OnChain   bool      `json:"onChain" validate:"omitempty"`
+	InvoiceID      uuid.UUID `json:"invoiceID" validate:"required"`
+	OnChain        bool      `json:"onChain" validate:"omitempty"`
+	IdempotencyKey string    `json:"-"`
 }
 
 type MerchantInvoiceResponse struct {
@@ -355,6 +358,7 @@ type PaymentRequest struct {
 	UserIdentifier string                 `json:"user_identifier"`
 	Metadata       map[string]interface{} `json:"metadata"`
 	ReferenceID    string                 `json:"reference_id"`
+	IdempotencyKey string                 `json:"-"`
 }
 
 type AddressDepositRequest struct {
@@ -364,13 +368,15 @@ type AddressDepositRequest struct {
 	Metadata                    map[string]interface{} `json:"metadata"`
 	PayWithCurrencyAbbreviation string                 `json:"pay_with_currency_abbreviation"`
 	ReferenceID                 string                 `json:"reference_id"`
+	IdempotencyKey              string                 `json:"-"`
 }
 
 type DepositResponse struct {
-	Message string `json:"message"`
-	Code    int    `json:"code"`
-	Status  string `json:"status"`
-	Data    struct {
+	Message        string `json:"message"`
+	Code           int    `json:"code"`
+	Status         string `json:"status"`
+	IdempotencyKey string `json:"-"`
+	Data           struct {
 		ID                      string  `json:"id"`
 		Address                 string  `json:"address"`
 		AmountToDeposit         float64 `json:"amountToDeposit"`
@@ -471,6 +477,7 @@ type CustomerPayout struct {
 	ReferenceId                   string  `json:"referenceId" validate:"omitempty"`
 	LongswipeUsernameOrEmail      string  `json:"longswipeUsernameOrEmail" validate:"omitempty"`
 	BlockchainNetworkAbbreviation string  `json:"blockchainNetworkAbbreviation" validate:"omitempty"`
+	IdempotencyKey                string  `json:"-"`
 }
 
 type Transactions struct {
@@ -1,16 +1,23 @@
 package longswipe
 
 import (
+	"context"
 	"net/url"
 	"strconv"
 )
 
 func (c *Client) FetchInvoice(body *Pagination) (*MerchantInvoiceResponse, error) {
-	endpoint := buildInvoiceEndpoint(body.Page, body.Limit, body.Search)
+	return c.FetchInvoiceWithContext(context.Background(), body)
+}
+
+func (c *Client) FetchInvoiceWithContext(ctx context.Context, body *Pagination) (*MerchantInvoiceResponse, error) {
+	method, endpoint := c.endpoint("FetchInvoice", nil, invoicesQuery(body.Page, body.Limit, body.Search))
 	var invoice MerchantInvoiceResponse
 
 	_, err := c.doRequestAndUnmarshal(
-		GET,
+		ctx,
+		"FetchInvoice",
+		method,
 		endpoint,
 		nil,
 		&invoice,
@@ -22,22 +29,28 @@ func (c *Client) FetchInvoice(body *Pagination) (*MerchantInvoiceResponse, error
 	return &invoice, nil
 }
 
-func buildInvoiceEndpoint(page int, limit int, filter string) string {
+func invoicesQuery(page int, limit int, filter string) url.Values {
 	params := url.Values{}
 	params.Add("page", strconv.Itoa(page))
 	params.Add("limit", strconv.Itoa(limit))
 	params.Add("filter", filter)
 
-	return "/merchant-integrations-server/fetch-invoice?" + params.Encode()
+	return params
 }
 
 func (c *Client) GetAllInvoiceCurrency() (*FetchAllAllowedInvoiceCurrencyResponse, error) {
-	endpoint := "/merchant-integrations-server/fetch-all-allowed-invoice-Currency"
+	return c.GetAllInvoiceCurrencyWithContext(context.Background())
+}
+
+func (c *Client) GetAllInvoiceCurrencyWithContext(ctx context.Context) (*FetchAllAllowedInvoiceCurrencyResponse, error) {
+	method, endpoint := c.endpoint("GetAllInvoiceCurrency", nil, nil)
 
 	var allowedCurrency FetchAllAllowedInvoiceCurrencyResponse
 
 	_, err := c.doRequestAndUnmarshal(
-		GET,
+		ctx,
+		"GetAllInvoiceCurrency",
+		method,
 		endpoint,
 		nil,
 		&allowedCurrency,
@@ -49,10 +62,16 @@ func (c *Client) GetAllInvoiceCurrency() (*FetchAllAllowedInvoiceCurrencyRespons
 	return &allowedCurrency, nil
 }
 func (c *Client) CreateInvoice(body *CreateInvoiceRequest) (*SuccessResponse, error) {
-	endpoint := "/merchant-integrations-server/create-invoice"
+	return c.CreateInvoiceWithContext(context.Background(), body)
+}
+
+func (c *Client) CreateInvoiceWithContext(ctx context.Context, body *CreateInvoiceRequest) (*SuccessResponse, error) {
+	method, endpoint := c.endpoint("CreateInvoice", nil, nil)
 	var res SuccessResponse
 	_, err := c.doRequestAndUnmarshal(
-		POST,
+		ctx,
+		"CreateInvoice",
+		method,
 		endpoint,
 		body,
 		&res,
@@ -65,11 +84,26 @@ func (c *Client) CreateInvoice(body *CreateInvoiceRequest) (*SuccessResponse, er
 }
 
 func (c *Client) ApproveInvoice(body *ApproveInvoiceRequest) (*SuccessResponse, error) {
-	endpoint := "/merchant-integrations-server/approve-invoice"
+	return c.ApproveInvoiceWithContext(context.Background(), body)
+}
+
+func (c *Client) ApproveInvoiceWithContext(ctx context.Context, body *ApproveInvoiceRequest) (*SuccessResponse, error) {
+	method, endpoint := c.endpoint("ApproveInvoice", nil, nil)
 	var res SuccessResponse
 
-	_, err := c.doRequestAndUnmarshal(
-		POST,
+	var suppliedKey string
+	if body != nil {
+		suppliedKey = body.IdempotencyKey
+	}
+	ctx, idempotencyKey, err := ensureIdempotencyKey(ctx, suppliedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = c.doRequestAndUnmarshal(
+		ctx,
+		"ApproveInvoice",
+		method,
 		endpoint,
 		body,
 		&res,
@@ -78,5 +112,6 @@ func (c *Client) ApproveInvoice(body *ApproveInvoiceRequest) (*SuccessResponse,
 	if err != nil {
 		return nil, err
 	}
+	res.IdempotencyKey = idempotencyKey
 	return &res, nil
 }
@@ -0,0 +1,78 @@
+package longswipe
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// LogConfig controls what the client writes to ClientConfig.Logger.
+// Credentials and lock PINs are always redacted.
+type LogConfig struct {
+	// Level is used for successful attempts. Defaults to slog.LevelDebug.
+	Level slog.Leveler
+	// ErrorLevel is used for failed attempts. Defaults to slog.LevelWarn.
+	ErrorLevel slog.Leveler
+	// Payloads adds redacted request headers and request and response bodies
+	// to each record.
+	Payloads bool
+	// EmailMasking controls how email addresses in paths and bodies are shown.
+	EmailMasking EmailMasking
+}
+
+type attemptLog struct {
+	req      *Request
+	attempt  int
+	header   http.Header
+	body     []byte
+	resp     *Response
+	err      error
+	duration time.Duration
+}
+
+func (c *Client) logAttempt(ctx context.Context, a attemptLog) {
+	if c.logger == nil {
+		return
+	}
+
+	level := slog.LevelDebug
+	if c.logConfig.Level != nil {
+		level = c.logConfig.Level.Level()
+	}
+	if a.err != nil {
+		level = slog.LevelWarn
+		if c.logConfig.ErrorLevel != nil {
+			level = c.logConfig.ErrorLevel.Level()
+		}
+	}
+	if !c.logger.Enabled(ctx, level) {
+		return
+	}
+
+	masking := c.logConfig.EmailMasking
+	attrs := []slog.Attr{
+		slog.String("operation", a.req.Operation),
+		slog.String("method", a.req.Method),
+		slog.String("path", masking.mask(a.req.Path)),
+		slog.Int("attempt", a.attempt),
+		slog.Duration("duration", a.duration),
+	}
+	if a.resp != nil {
+		attrs = append(attrs, slog.Int("status", a.resp.StatusCode))
+	}
+	if a.err != nil {
+		attrs = append(attrs, slog.String("error", masking.mask(a.err.Error())))
+	}
+	if c.logConfig.Payloads {
+		attrs = append(attrs,
+			slog.Any("request_headers", redactHeader(a.header)),
+			slog.String("request_body", redactBody(a.body, masking, nil)),
+		)
+		if a.resp != nil {
+			attrs = append(attrs, slog.String("response_body", redactBody(a.resp.Body, masking, nil)))
+		}
+	}
+
+	c.logger.LogAttrs(ctx, level, "longswipe request", attrs...)
+}
@@ -0,0 +1,54 @@
+package longswipe
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// ResponseMeta describes the HTTP exchange behind a call. Pass one to
+// WithResponseMeta to have it filled in.
+type ResponseMeta struct {
+	// StatusCode is the HTTP status of the last attempt, or 0 if no response
+	// was received.
+	StatusCode int
+	Header     http.Header
+	// RequestID is the X-Request-Id header, for LongSwipe support requests.
+	RequestID string
+	// Date is the server's Date header, or the zero time if absent.
+	Date time.Time
+	// Latency is the duration of the whole call, including retries.
+	Latency time.Duration
+	// Quota is the rate-limit state reported by the API, if HasQuota is set.
+	Quota    Quota
+	HasQuota bool
+}
+
+type responseMetaCtxKey struct{}
+
+// WithResponseMeta returns a copy of ctx that makes calls made with it fill in
+// meta when they return, whether or not they succeed. Use a separate
+// ResponseMeta for concurrent calls.
+func WithResponseMeta(ctx context.Context, meta *ResponseMeta) context.Context {
+	return context.WithValue(ctx, responseMetaCtxKey{}, meta)
+}
+
+// recordResponseMeta fills in the ResponseMeta carried by ctx, if any.
+func recordResponseMeta(ctx context.Context, resp *Response, latency time.Duration) {
+	meta, _ := ctx.Value(responseMetaCtxKey{}).(*ResponseMeta)
+	if meta == nil {
+		return
+	}
+
+	*meta = ResponseMeta{Latency: latency}
+	if resp == nil {
+		return
+	}
+	meta.StatusCode = resp.StatusCode
+	meta.Header = resp.Header
+	meta.RequestID = resp.Header.Get("X-Request-Id")
+	if date, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+		meta.Date = date
+	}
+	meta.Quota, meta.HasQuota = parseQuota(resp.Header, time.Now())
+}
@@ -0,0 +1,157 @@
+package longswipe
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultLatencyBuckets are the upper bounds, in seconds, of the latency
+// histogram used when NewMetrics is called without buckets.
+var DefaultLatencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Metrics collects request counts, error counts and latency histograms per
+// SDK operation and HTTP status, labelled with the client's MetricsLabels. It
+// implements http.Handler, serving the collected metrics in the Prometheus
+// text exposition format. A Metrics may be shared by several clients.
+type Metrics struct {
+	buckets []float64
+
+	mu       sync.Mutex
+	requests map[string]uint64
+	errors   map[string]uint64
+	latency  map[string]*histogram
+}
+
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// NewMetrics returns an empty collector using the given latency buckets in
+// seconds, or DefaultLatencyBuckets if none are given.
+func NewMetrics(buckets ...float64) *Metrics {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+
+	return &Metrics{
+		buckets:  buckets,
+		requests: make(map[string]uint64),
+		errors:   make(map[string]uint64),
+		latency:  make(map[string]*histogram),
+	}
+}
+
+// observe records a finished call. status is 0 when no response was received.
+// constLabels are the client's MetricsLabels.
+func (m *Metrics) observe(constLabels map[string]string, operation string, status int, failed bool, duration time.Duration) {
+	statusLabel := "none"
+	if status != 0 {
+		statusLabel = strconv.Itoa(status)
+	}
+
+	pairs := make([]string, 0, 2*len(constLabels)+4)
+	for _, name := range sortedKeys(constLabels) {
+		pairs = append(pairs, name, constLabels[name])
+	}
+	pairs = append(pairs, "operation", operation)
+	opLabels := formatLabels(pairs...)
+	statusLabels := formatLabels(append(pairs, "status", statusLabel)...)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests[statusLabels]++
+	if failed {
+		m.errors[statusLabels]++
+	}
+
+	h, ok := m.latency[opLabels]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(m.buckets))}
+		m.latency[opLabels] = h
+	}
+	seconds := duration.Seconds()
+	for i, upper := range m.buckets {
+		if seconds <= upper {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += seconds
+}
+
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write(m.exposition())
+}
+
+func (m *Metrics) exposition() []byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var buf bytes.Buffer
+
+	buf.WriteString("# HELP longswipe_requests_total Total LongSwipe API calls by operation and HTTP status.\n")
+	buf.WriteString("# TYPE longswipe_requests_total counter\n")
+	for _, labels := range sortedKeys(m.requests) {
+		fmt.Fprintf(&buf, "longswipe_requests_total{%s} %d\n", labels, m.requests[labels])
+	}
+
+	buf.WriteString("# HELP longswipe_request_errors_total Failed LongSwipe API calls by operation and HTTP status.\n")
+	buf.WriteString("# TYPE longswipe_request_errors_total counter\n")
+	for _, labels := range sortedKeys(m.errors) {
+		fmt.Fprintf(&buf, "longswipe_request_errors_total{%s} %d\n", labels, m.errors[labels])
+	}
+
+	buf.WriteString("# HELP longswipe_request_duration_seconds Latency of LongSwipe API calls by operation.\n")
+	buf.WriteString("# TYPE longswipe_request_duration_seconds histogram\n")
+	for _, labels := range sortedKeys(m.latency) {
+		h := m.latency[labels]
+		for i, upper := range m.buckets {
+			fmt.Fprintf(&buf, "longswipe_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n",
+				labels, strconv.FormatFloat(upper, 'g', -1, 64), h.counts[i])
+		}
+		fmt.Fprintf(&buf, "longswipe_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, h.count)
+		fmt.Fprintf(&buf, "longswipe_request_duration_seconds_sum{%s} %s\n", labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(&buf, "longswipe_request_duration_seconds_count{%s} %d\n", labels, h.count)
+	}
+
+	return buf.Bytes()
+}
+
+// formatLabels renders name/value pairs as a Prometheus label set without
+// the surrounding braces.
+func formatLabels(pairs ...string) string {
+	var b strings.Builder
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(pairs[i])
+		b.WriteString(`="`)
+		b.WriteString(labelEscaper.Replace(pairs[i+1]))
+		b.WriteByte('"')
+	}
+	return b.String()
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,86 @@
+package longswipe
+
+import (
+	"context"
+	"net/http"
+)
+
+// Request describes an SDK call as it passes through the middleware chain.
+type Request struct {
+	// Operation is the name of the SDK method, e.g. "PayoutToLongSwipeUser".
+	Operation string
+	Method    string
+	// Path is the endpoint path, including any query string, relative to the
+	// client's base URL and API version prefix. Path parameters are escaped.
+	Path string
+	// Body is the request payload before JSON encoding, or nil.
+	Body interface{}
+	// Header holds extra headers sent with every attempt. Authentication
+	// headers are added by the client after the middleware chain runs.
+	Header http.Header
+	// KeepBody makes the client buffer a successful response so that
+	// Response.Body holds the raw bytes. Without it a successful response is
+	// decoded as it is read and Body is nil. Middleware that needs the raw
+	// body sets it before calling next.
+	KeepBody bool
+
+	// into, when set, receives the decoded body of a successful response.
+	into interface{}
+}
+
+// Response is the raw outcome of an SDK call. It is nil when no response was
+// received from the API.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	// Body is nil when a successful response was decoded as it was read;
+	// set Request.KeepBody to receive it.
+	Body []byte
+
+	decoded bool
+}
+
+// Handler executes a Request.
+type Handler func(ctx context.Context, req *Request) (*Response, error)
+
+// Middleware wraps a Handler to add behaviour around every SDK call, such as
+// audit logging, header injection or metrics. A Middleware may inspect or
+// modify the Request before calling next, and inspect the Response and error
+// it returns.
+//
+// Middleware sees every call, including calls served from the response cache
+// and calls coalesced into another caller's request. Headers a middleware sets
+// on such calls are not sent, since no request of their own reaches the API.
+type Middleware func(next Handler) Handler
+
+// Use appends middleware to the client's chain. The first middleware
+// registered is the outermost one, so it sees the call first and its
+// result last.
+func (c *Client) Use(middleware ...Middleware) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	chain := make([]Middleware, 0, len(c.middleware)+len(middleware))
+	chain = append(chain, c.middleware...)
+	c.middleware = append(chain, middleware...)
+}
+
+func (c *Client) handler() Handler {
+	c.mu.RLock()
+	middleware := c.middleware
+	c.mu.RUnlock()
+
+	// The cache and request coalescing sit inside the chain, so middleware
+	// sees every call, including those served from the cache or shared.
+	h := Handler(c.execute)
+	if c.flights != nil {
+		h = c.flights.coalesce(h)
+	}
+	if c.cache != nil {
+		h = c.cache.serve(h)
+	}
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](h)
+	}
+	return h
+}
@@ -0,0 +1,282 @@
+package longswipe
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const sdkUserAgent = "LongSwipe-Go-SDK/v1"
+
+// Option customises a ClientConfig passed to NewClientWithOptions.
+type Option func(*ClientConfig)
+
+// WithHTTPClient makes the client send requests through httpClient.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *ClientConfig) {
+		c.HTTPClient = httpClient
+	}
+}
+
+// WithTransport makes the SDK-built http.Client use transport, e.g. to route
+// requests through a proxy or a test double.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *ClientConfig) {
+		c.Transport = transport
+	}
+}
+
+// WithTLSConfig replaces the default TLS configuration of the SDK-built
+// transport.
+func WithTLSConfig(tlsConfig *tls.Config) Option {
+	return func(c *ClientConfig) {
+		c.TLSConfig = tlsConfig
+	}
+}
+
+// WithUserAgent prepends userAgent to the SDK's User-Agent header.
+func WithUserAgent(userAgent string) Option {
+	return func(c *ClientConfig) {
+		c.UserAgent = userAgent
+	}
+}
+
+// WithBaseURL sets the API base URL, usually PRODUCTION or SANDBOX.
+func WithBaseURL(baseURL string) Option {
+	return func(c *ClientConfig) {
+		c.BaseURL = baseURL
+	}
+}
+
+// WithTimeout sets the per-attempt HTTP timeout.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *ClientConfig) {
+		c.Timeout = timeout
+	}
+}
+
+// WithRateLimit enables client-side rate limiting.
+func WithRateLimit(config RateLimitConfig) Option {
+	return func(c *ClientConfig) {
+		c.RateLimit = &config
+	}
+}
+
+// WithCircuitBreaker enables the circuit breaker.
+func WithCircuitBreaker(config CircuitBreakerConfig) Option {
+	return func(c *ClientConfig) {
+		c.CircuitBreaker = &config
+	}
+}
+
+// WithLogger makes the client log every attempt to logger.
+func WithLogger(logger *slog.Logger, config LogConfig) Option {
+	return func(c *ClientConfig) {
+		c.Logger = logger
+		c.Log = config
+	}
+}
+
+// WithTracer makes the client start a span around every call.
+func WithTracer(tracer Tracer) Option {
+	return func(c *ClientConfig) {
+		c.Tracer = tracer
+	}
+}
+
+// WithMetrics makes the client record every call in metrics.
+func WithMetrics(metrics *Metrics) Option {
+	return func(c *ClientConfig) {
+		c.Metrics = metrics
+	}
+}
+
+// WithCredentialsProvider makes the client fetch its API keys from provider
+// on every request.
+func WithCredentialsProvider(provider CredentialsProvider) Option {
+	return func(c *ClientConfig) {
+		c.Credentials = provider
+	}
+}
+
+// WithMaxResponseSize caps the number of bytes read from a response body. A
+// negative size removes the cap.
+func WithMaxResponseSize(size int64) Option {
+	return func(c *ClientConfig) {
+		c.MaxResponseSize = size
+	}
+}
+
+// WithRootCAs sets the certificate pool used to verify the API's
+// certificate.
+func WithRootCAs(pool *x509.CertPool) Option {
+	return func(c *ClientConfig) {
+		c.RootCAs = pool
+	}
+}
+
+// WithClientCertificate adds a certificate presented for mutual TLS.
+func WithClientCertificate(cert tls.Certificate) Option {
+	return func(c *ClientConfig) {
+		c.ClientCertificates = append(c.ClientCertificates, cert)
+	}
+}
+
+// WithPins enables certificate pinning against pins.
+func WithPins(pins *PinSet) Option {
+	return func(c *ClientConfig) {
+		c.Pins = pins
+	}
+}
+
+// WithProxy sends every request through the proxy at proxyURL.
+func WithProxy(proxyURL *url.URL) Option {
+	return func(c *ClientConfig) {
+		c.Proxy = http.ProxyURL(proxyURL)
+	}
+}
+
+// WithLowQuotaCallback calls fn whenever the remaining requests the API
+// reports for an endpoint group drop below threshold.
+func WithLowQuotaCallback(threshold int, fn func(group string, quota Quota)) Option {
+	return func(c *ClientConfig) {
+		c.LowQuotaThreshold = threshold
+		c.OnLowQuota = fn
+	}
+}
+
+// WithDebug dumps every HTTP request and response to w. When redactPII is
+// set, personal data is redacted as well as credentials.
+func WithDebug(w io.Writer, redactPII bool) Option {
+	return func(c *ClientConfig) {
+		c.Debug = &DebugConfig{Writer: w, RedactPII: redactPII}
+	}
+}
+
+// WithCache enables response caching.
+func WithCache(config CacheConfig) Option {
+	return func(c *ClientConfig) {
+		c.Cache = &config
+	}
+}
+
+// WithCoalescing collapses concurrent identical GET calls of the given
+// operations into a single HTTP request. Every caller's middleware runs, but
+// the request is sent with the headers of the caller that started it.
+func WithCoalescing(operations ...string) Option {
+	return func(c *ClientConfig) {
+		c.Coalesce = append(c.Coalesce, operations...)
+	}
+}
+
+// WithRetryPolicy sets the retry policy.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *ClientConfig) {
+		c.Retry = policy
+	}
+}
+
+func (c ClientConfig) validate() error {
+	var errs []error
+
+	if c.BaseURL == "" {
+		errs = append(errs, errors.New("BaseURL is required"))
+	} else if u, err := url.Parse(c.BaseURL); err != nil {
+		errs = append(errs, fmt.Errorf("invalid BaseURL: %w", err))
+	} else if (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		errs = append(errs, fmt.Errorf("invalid BaseURL %q: must be an absolute http(s) URL", c.BaseURL))
+	}
+
+	if c.Credentials == nil {
+		if c.PublicKey == "" {
+			errs = append(errs, errors.New("PublicKey is required"))
+		}
+		if c.PrivateKey == "" {
+			errs = append(errs, errors.New("PrivateKey is required"))
+		}
+	}
+	if err := c.checkKeyMode(); err != nil {
+		errs = append(errs, err)
+	}
+	if c.Timeout < 0 {
+		errs = append(errs, errors.New("Timeout must not be negative"))
+	}
+	if c.Retry.MaxAttempts < 0 {
+		errs = append(errs, errors.New("Retry.MaxAttempts must not be negative"))
+	}
+	if c.Retry.Jitter < 0 || c.Retry.Jitter > 1 {
+		errs = append(errs, errors.New("Retry.Jitter must be between 0 and 1"))
+	}
+
+	if c.RateLimit != nil {
+		if c.RateLimit.Rate < 0 || c.RateLimit.Burst < 0 {
+			errs = append(errs, errors.New("RateLimit rate and burst must not be negative"))
+		}
+		for key, limit := range c.RateLimit.Buckets {
+			if limit.Rate < 0 || limit.Burst < 0 {
+				errs = append(errs, fmt.Errorf("RateLimit bucket %q: rate and burst must not be negative", key))
+			}
+		}
+	}
+
+	if err := checkRoutes(c.Routes); err != nil {
+		errs = append(errs, err)
+	}
+	for _, operation := range c.Coalesce {
+		if !knownOperation(operation) {
+			errs = append(errs, fmt.Errorf("Coalesce: unknown operation %q", operation))
+		}
+	}
+
+	if c.Cache != nil {
+		if c.Cache.StaleWhileRevalidate < 0 || c.Cache.StaleIfError < 0 {
+			errs = append(errs, errors.New("Cache durations must not be negative"))
+		}
+		for _, operation := range sortedKeys(c.Cache.TTLs) {
+			if !knownOperation(operation) {
+				errs = append(errs, fmt.Errorf("Cache: unknown operation %q", operation))
+			} else if c.Cache.TTLs[operation] <= 0 {
+				errs = append(errs, fmt.Errorf("Cache TTL for %q must be positive", operation))
+			}
+		}
+	}
+
+	if c.Debug != nil && c.Debug.Writer == nil {
+		errs = append(errs, errors.New("Debug.Writer is required"))
+	}
+
+	if c.CircuitBreaker != nil && c.CircuitBreaker.FailureRatio > 1 {
+		errs = append(errs, errors.New("CircuitBreaker.FailureRatio must not exceed 1"))
+	}
+
+	if c.HTTPClient != nil && c.Transport != nil {
+		errs = append(errs, errors.New("HTTPClient and Transport are mutually exclusive"))
+	}
+	if c.HTTPClient != nil || c.Transport != nil {
+		for _, field := range []struct {
+			name string
+			set  bool
+		}{
+			{"TLSConfig", c.TLSConfig != nil},
+			{"RootCAs", c.RootCAs != nil},
+			{"ClientCertificates", len(c.ClientCertificates) > 0},
+			{"Pins", c.Pins != nil},
+			{"Proxy", c.Proxy != nil},
+		} {
+			if field.set {
+				errs = append(errs, fmt.Errorf("%s only applies to the SDK-built transport and cannot be combined with HTTPClient or Transport", field.name))
+			}
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("longswipe: invalid client configuration: %w", err)
+	}
+	return nil
+}
@@ -1,13 +1,31 @@
 package longswipe
 
-import "fmt"
+import (
+	"context"
+	"net/url"
+)
 
 func (c *Client) PaymentRequest(body *PaymentRequest) (*SuccessResponse, error) {
-	endpoint := "/merchant-integrations/payment-request"
+	return c.PaymentRequestWithContext(context.Background(), body)
+}
+
+func (c *Client) PaymentRequestWithContext(ctx context.Context, body *PaymentRequest) (*SuccessResponse, error) {
+	method, endpoint := c.endpoint("PaymentRequest", nil, nil)
 	var res SuccessResponse
 
-	_, err := c.doRequestAndUnmarshal(
-		POST,
+	var suppliedKey string
+	if body != nil {
+		suppliedKey = body.IdempotencyKey
+	}
+	ctx, idempotencyKey, err := ensureIdempotencyKey(ctx, suppliedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = c.doRequestAndUnmarshal(
+		ctx,
+		"PaymentRequest",
+		method,
 		endpoint,
 		body,
 		&res,
@@ -16,15 +34,31 @@ func (c *Client) PaymentRequest(body *PaymentRequest) (*SuccessResponse, error)
 	if err != nil {
 		return nil, err
 	}
+	res.IdempotencyKey = idempotencyKey
 	return &res, nil
 }
 
 func (c *Client) AddressDepositRequest(body *AddressDepositRequest) (*DepositResponse, error) {
-	endpoint := "/merchant-integrations/deposit-address-payment-request"
+	return c.AddressDepositRequestWithContext(context.Background(), body)
+}
+
+func (c *Client) AddressDepositRequestWithContext(ctx context.Context, body *AddressDepositRequest) (*DepositResponse, error) {
+	method, endpoint := c.endpoint("AddressDepositRequest", nil, nil)
 	var res DepositResponse
 
-	_, err := c.doRequestAndUnmarshal(
-		POST,
+	var suppliedKey string
+	if body != nil {
+		suppliedKey = body.IdempotencyKey
+	}
+	ctx, idempotencyKey, err := ensureIdempotencyKey(ctx, suppliedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = c.doRequestAndUnmarshal(
+		ctx,
+		"AddressDepositRequest",
+		method,
 		endpoint,
 		body,
 		&res,
@@ -33,15 +67,22 @@ func (c *Client) AddressDepositRequest(body *AddressDepositRequest) (*DepositRes
 	if err != nil {
 		return nil, err
 	}
+	res.IdempotencyKey = idempotencyKey
 	return &res, nil
 }
 
 func (c *Client) DepositCharges(body *AddressDepositChargeRequest) (*ChargeEstimateResponse, error) {
-	endpoint := "/merchant-integrations/request-wallet-deposit-charges"
+	return c.DepositChargesWithContext(context.Background(), body)
+}
+
+func (c *Client) DepositChargesWithContext(ctx context.Context, body *AddressDepositChargeRequest) (*ChargeEstimateResponse, error) {
+	method, endpoint := c.endpoint("DepositCharges", nil, nil)
 	var charges ChargeEstimateResponse
 
 	_, err := c.doRequestAndUnmarshal(
-		POST,
+		ctx,
+		"DepositCharges",
+		method,
 		endpoint,
 		body,
 		&charges,
@@ -54,11 +95,17 @@ func (c *Client) DepositCharges(body *AddressDepositChargeRequest) (*ChargeEstim
 }
 
 func (c *Client) VerifyTransaction(referenceId string) (*TransactionResponse, error) {
-	endpoint := fmt.Sprintf("/merchant-integrations-server/verify-transaction/%s", referenceId)
+	return c.VerifyTransactionWithContext(context.Background(), referenceId)
+}
+
+func (c *Client) VerifyTransactionWithContext(ctx context.Context, referenceId string) (*TransactionResponse, error) {
+	method, endpoint := c.endpoint("VerifyTransaction", map[string]string{"referenceId": referenceId}, nil)
 	var transaction TransactionResponse
 
 	_, err := c.doRequestAndUnmarshal(
-		GET,
+		ctx,
+		"VerifyTransaction",
+		method,
 		endpoint,
 		nil,
 		&transaction,
@@ -71,11 +118,17 @@ func (c *Client) VerifyTransaction(referenceId string) (*TransactionResponse, er
 }
 
 func (c *Client) ConfirmUser(identifier string) (*ConfirmUserDetailsResponse, error) {
-	endpoint := fmt.Sprintf("/merchant-integrations/confirm-user/%s", identifier)
+	return c.ConfirmUserWithContext(context.Background(), identifier)
+}
+
+func (c *Client) ConfirmUserWithContext(ctx context.Context, identifier string) (*ConfirmUserDetailsResponse, error) {
+	method, endpoint := c.endpoint("ConfirmUser", map[string]string{"identifier": identifier}, nil)
 	var userProfile ConfirmUserDetailsResponse
 
 	_, err := c.doRequestAndUnmarshal(
-		GET,
+		ctx,
+		"ConfirmUser",
+		method,
 		endpoint,
 		nil,
 		&userProfile,
@@ -88,11 +141,26 @@ func (c *Client) ConfirmUser(identifier string) (*ConfirmUserDetailsResponse, er
 }
 
 func (c *Client) PayoutToLongSwipeUser(body *CustomerPayout) (*SuccessResponse, error) {
-	endpoint := "/merchant-integrations-server/payout"
+	return c.PayoutToLongSwipeUserWithContext(context.Background(), body)
+}
+
+func (c *Client) PayoutToLongSwipeUserWithContext(ctx context.Context, body *CustomerPayout) (*SuccessResponse, error) {
+	method, endpoint := c.endpoint("PayoutToLongSwipeUser", nil, nil)
 	var res SuccessResponse
 
-	_, err := c.doRequestAndUnmarshal(
-		POST,
+	var suppliedKey string
+	if body != nil {
+		suppliedKey = body.IdempotencyKey
+	}
+	ctx, idempotencyKey, err := ensureIdempotencyKey(ctx, suppliedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = c.doRequestAndUnmarshal(
+		ctx,
+		"PayoutToLongSwipeUser",
+		method,
 		endpoint,
 		body,
 		&res,
@@ -101,15 +169,22 @@ func (c *Client) PayoutToLongSwipeUser(body *CustomerPayout) (*SuccessResponse,
 	if err != nil {
 		return nil, err
 	}
+	res.IdempotencyKey = idempotencyKey
 	return &res, nil
 }
 
 func (c *Client) AccountBalance(currencyAbbreviation string) (*PublicBalanceResponse, error) {
-	endpoint := fmt.Sprintf("/merchant-integrations/fetch-balance?currencyAbbreviation=%s", currencyAbbreviation)
+	return c.AccountBalanceWithContext(context.Background(), currencyAbbreviation)
+}
+
+func (c *Client) AccountBalanceWithContext(ctx context.Context, currencyAbbreviation string) (*PublicBalanceResponse, error) {
+	method, endpoint := c.endpoint("AccountBalance", nil, url.Values{"currencyAbbreviation": {currencyAbbreviation}})
 	var balance PublicBalanceResponse
 
 	_, err := c.doRequestAndUnmarshal(
-		GET,
+		ctx,
+		"AccountBalance",
+		method,
 		endpoint,
 		nil,
 		&balance,
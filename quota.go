@@ -0,0 +1,96 @@
+package longswipe
+
+import (
+	"maps"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Quota is the API's rate-limit state as reported in the RateLimit-* or
+// X-RateLimit-* headers of a response.
+type Quota struct {
+	// Limit is the number of requests allowed in the current window.
+	Limit int
+	// Remaining is the number of requests left in the current window, if
+	// HasRemaining is set.
+	Remaining    int
+	HasRemaining bool
+	// Reset is when the window resets, or the zero time if unknown.
+	Reset time.Time
+}
+
+// parseQuota reads the rate-limit headers of a response. It returns false if
+// the response carries none. Reset may be given in seconds from now or as a
+// Unix timestamp.
+func parseQuota(header http.Header, now time.Time) (Quota, bool) {
+	get := func(name string) (int64, bool) {
+		value := header.Get("RateLimit-" + name)
+		if value == "" {
+			value = header.Get("X-RateLimit-" + name)
+		}
+		n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		return n, err == nil && n >= 0
+	}
+
+	limit, hasLimit := get("Limit")
+	remaining, hasRemaining := get("Remaining")
+	if !hasLimit && !hasRemaining {
+		return Quota{}, false
+	}
+
+	q := Quota{Limit: int(limit), Remaining: int(remaining), HasRemaining: hasRemaining}
+	if reset, ok := get("Reset"); ok {
+		// Delays are small; anything past 2001 is a Unix timestamp.
+		if reset > 1e9 {
+			q.Reset = time.Unix(reset, 0)
+		} else {
+			q.Reset = now.Add(time.Duration(reset) * time.Second)
+		}
+	}
+	return q, true
+}
+
+// observeQuota records the quota reported by resp for the request's endpoint
+// group and calls OnLowQuota when the remaining requests drop below
+// LowQuotaThreshold. Responses that do not report the remaining requests
+// never trigger it.
+func (c *Client) observeQuota(req *Request, resp *Response) {
+	if resp == nil {
+		return
+	}
+	quota, ok := parseQuota(resp.Header, time.Now())
+	if !ok {
+		return
+	}
+
+	group := endpointGroup(req.Path)
+	c.quotaMu.Lock()
+	prev, seen := c.quotas[group]
+	c.quotas[group] = quota
+	c.quotaMu.Unlock()
+
+	low := quota.HasRemaining && quota.Remaining < c.quotaFloor
+	wasLow := seen && prev.HasRemaining && prev.Remaining < c.quotaFloor
+	if c.onLowQuota != nil && low && !wasLow {
+		c.onLowQuota(group, quota)
+	}
+}
+
+// Quota returns the latest quota the API reported for an endpoint group, such
+// as "merchant-integrations".
+func (c *Client) Quota(group string) (Quota, bool) {
+	c.quotaMu.Lock()
+	defer c.quotaMu.Unlock()
+	quota, ok := c.quotas[group]
+	return quota, ok
+}
+
+// Quotas returns the latest quota the API reported for every endpoint group
+// called so far.
+func (c *Client) Quotas() map[string]Quota {
+	c.quotaMu.Lock()
+	defer c.quotaMu.Unlock()
+	return maps.Clone(c.quotas)
+}
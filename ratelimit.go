@@ -0,0 +1,186 @@
+package longswipe
+
+import (
+	"context"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimit describes a token bucket that refills at Rate requests per second
+// and holds at most Burst tokens. A zero Rate leaves the bucket unlimited.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimitConfig enables client-side rate limiting. Requests wait for a token
+// from their bucket before being sent, respecting the call's context. Buckets
+// are keyed by endpoint group, the first path segment such as
+// "merchant-integrations" or "merchant-integrations-server", or by SDK
+// operation name when PerOperation is set.
+//
+// When the API answers 429 the bucket halves its rate and pauses until the
+// Retry-After delay has passed, then recovers gradually on successful calls.
+type RateLimitConfig struct {
+	// RateLimit applies to every bucket without an entry in Buckets.
+	RateLimit
+	PerOperation bool
+	// Buckets overrides the limit for individual groups or operations.
+	Buckets map[string]RateLimit
+}
+
+type rateLimiter struct {
+	config RateLimitConfig
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+func newRateLimiter(config *RateLimitConfig) *rateLimiter {
+	if config == nil {
+		return nil
+	}
+	return &rateLimiter{
+		config:  *config,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+func (l *rateLimiter) key(req *Request) string {
+	if l.config.PerOperation {
+		return req.Operation
+	}
+	return endpointGroup(req.Path)
+}
+
+func (l *rateLimiter) bucket(key string) *tokenBucket {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[key]
+	if !ok {
+		limit, ok := l.config.Buckets[key]
+		if !ok {
+			limit = l.config.RateLimit
+		}
+		b = newTokenBucket(limit)
+		l.buckets[key] = b
+	}
+	return b
+}
+
+// wait blocks until the request may be sent or ctx is done.
+func (l *rateLimiter) wait(ctx context.Context, req *Request) error {
+	b := l.bucket(l.key(req))
+	for {
+		delay := b.take(time.Now())
+		if delay == 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// observe adapts the request's bucket to the status code the API returned.
+func (l *rateLimiter) observe(req *Request, resp *Response) {
+	if resp == nil {
+		return
+	}
+	b := l.bucket(l.key(req))
+	if resp.StatusCode == 429 {
+		pause, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		if !ok {
+			pause = time.Second
+		}
+		b.throttle(time.Now(), pause)
+		return
+	}
+	if resp.StatusCode < 400 {
+		b.relax()
+	}
+}
+
+type tokenBucket struct {
+	mu          sync.Mutex
+	limit       RateLimit
+	rate        float64
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+}
+
+func newTokenBucket(limit RateLimit) *tokenBucket {
+	if limit.Burst < 1 {
+		limit.Burst = 1
+	}
+	return &tokenBucket{
+		limit:  limit,
+		rate:   limit.Rate,
+		tokens: float64(limit.Burst),
+	}
+}
+
+// take consumes a token and returns 0, or returns how long to wait before
+// trying again.
+func (b *tokenBucket) take(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if now.Before(b.pausedUntil) {
+		return b.pausedUntil.Sub(now)
+	}
+	if b.limit.Rate <= 0 {
+		return 0
+	}
+	if !b.last.IsZero() {
+		elapsed := now.Sub(b.last).Seconds()
+		b.tokens = math.Min(float64(b.limit.Burst), b.tokens+elapsed*b.rate)
+	}
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+func (b *tokenBucket) throttle(now time.Time, pause time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.limit.Rate > 0 {
+		b.rate = math.Max(b.rate/2, b.limit.Rate/16)
+	}
+	b.tokens = 0
+	b.last = now
+	if until := now.Add(pause); until.After(b.pausedUntil) {
+		b.pausedUntil = until
+	}
+}
+
+func (b *tokenBucket) relax() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.rate = math.Min(b.limit.Rate, b.rate+b.limit.Rate/10)
+}
+
+// endpointGroup returns the first segment of path, e.g.
+// "merchant-integrations-server" for "/merchant-integrations-server/payout".
+func endpointGroup(path string) string {
+	path = strings.TrimPrefix(path, "/")
+	if i := strings.IndexAny(path, "/?"); i >= 0 {
+		path = path[:i]
+	}
+	return path
+}
@@ -0,0 +1,100 @@
+package longswipe
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// EmailMasking controls how email addresses are shown in logs.
+type EmailMasking int
+
+const (
+	// MaskEmailsPartial keeps the first character and the domain,
+	// e.g. "j***@example.com".
+	MaskEmailsPartial EmailMasking = iota
+	// MaskEmailsFull replaces email addresses entirely.
+	MaskEmailsFull
+	// MaskEmailsNone leaves email addresses untouched.
+	MaskEmailsNone
+)
+
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+
+// secretHeaders are never written out by the SDK.
+var secretHeaders = []string{"Authorization", "X-API-Private-Key"}
+
+// secretFields are JSON fields whose values are never written out by the SDK.
+var secretFields = map[string]bool{
+	"lockpin": true,
+}
+
+func (m EmailMasking) mask(s string) string {
+	switch m {
+	case MaskEmailsNone:
+		return s
+	case MaskEmailsFull:
+		return emailPattern.ReplaceAllString(s, redacted)
+	}
+	return emailPattern.ReplaceAllStringFunc(s, func(email string) string {
+		at := strings.LastIndexByte(email, '@')
+		return email[:1] + "***" + email[at:]
+	})
+}
+
+// redactHeader returns a copy of h with credentials replaced.
+func redactHeader(h http.Header) http.Header {
+	out := h.Clone()
+	for _, name := range secretHeaders {
+		if out.Get(name) != "" {
+			out.Set(name, redacted)
+		}
+	}
+	return out
+}
+
+// redactBody returns a copy of a JSON body with secret fields and the given
+// extra fields replaced, and email addresses masked. Bodies that are not valid
+// JSON are only email-masked.
+func redactBody(body []byte, masking EmailMasking, extraFields map[string]bool) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		return masking.mask(string(body))
+	}
+
+	out, err := json.Marshal(redactValue(decoded, masking, extraFields))
+	if err != nil {
+		return redacted
+	}
+	return string(out)
+}
+
+func redactValue(v interface{}, masking EmailMasking, extraFields map[string]bool) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			lower := strings.ToLower(key)
+			if secretFields[lower] || extraFields[lower] {
+				v[key] = redacted
+				continue
+			}
+			v[key] = redactValue(value, masking, extraFields)
+		}
+		return v
+	case []interface{}:
+		for i, value := range v {
+			v[i] = redactValue(value, masking, extraFields)
+		}
+		return v
+	case string:
+		return masking.mask(v)
+	}
+	return v
+}
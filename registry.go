@@ -0,0 +1,220 @@
+package longswipe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// ErrUnknownMerchant is returned by calls made through Registry.For for a
+// merchant that has not been registered.
+var ErrUnknownMerchant = errors.New("longswipe: unknown merchant")
+
+// MerchantLabel is the metrics label set to the merchant identifier on
+// clients created by a Registry.
+const MerchantLabel = "merchant"
+
+// Registry holds one Client per merchant account. All clients share a single
+// http.Client, and therefore its connection pool, while keeping their own
+// keys, rate limits and metrics labels.
+type Registry struct {
+	httpClient *http.Client
+	shared     []Option
+
+	mu      sync.RWMutex
+	clients map[string]*Client
+}
+
+// NewRegistry returns an empty registry. opts are applied to every merchant's
+// configuration; transport-level options (WithHTTPClient, WithTransport,
+// WithTimeout and the TLS and proxy options) configure the shared
+// http.Client.
+func NewRegistry(opts ...Option) *Registry {
+	var config ClientConfig
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	return &Registry{
+		httpClient: newHTTPClient(config),
+		shared:     opts,
+		clients:    make(map[string]*Client),
+	}
+}
+
+// Register creates the client for merchantID, replacing any existing client
+// for that merchant. The registry's shared options provide the defaults and
+// any field set in config overrides them. Transport-level fields cannot be set
+// per merchant, since all clients share the registry's http.Client.
+func (r *Registry) Register(merchantID string, config ClientConfig) (*Client, error) {
+	if merchantID == "" {
+		return nil, errors.New("longswipe: merchant identifier is required")
+	}
+	if config.HTTPClient != nil || config.Transport != nil || config.TLSConfig != nil ||
+		config.RootCAs != nil || config.ClientCertificates != nil || config.Pins != nil ||
+		config.Proxy != nil || config.Timeout != 0 {
+		return nil, fmt.Errorf("longswipe: merchant %q: transport options and Timeout must be set on the registry", merchantID)
+	}
+
+	var base ClientConfig
+	for _, opt := range r.shared {
+		opt(&base)
+	}
+	base.HTTPClient = r.httpClient
+	base.Transport = nil
+	base.TLSConfig = nil
+	base.RootCAs = nil
+	base.ClientCertificates = nil
+	base.Pins = nil
+	base.Proxy = nil
+	base.Timeout = 0
+	config = mergeConfig(base, config)
+
+	labels := maps.Clone(config.MetricsLabels)
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	labels[MerchantLabel] = merchantID
+	config.MetricsLabels = labels
+
+	client, err := NewClientWithOptions(config)
+	if err != nil {
+		return nil, fmt.Errorf("merchant %q: %w", merchantID, err)
+	}
+
+	r.mu.Lock()
+	r.clients[merchantID] = client
+	r.mu.Unlock()
+	return client, nil
+}
+
+// mergeConfig returns base with every non-zero field of override applied on
+// top. Middleware and metrics labels from both are combined, with override's
+// labels winning.
+func mergeConfig(base, override ClientConfig) ClientConfig {
+	if override.BaseURL != "" {
+		base.BaseURL = override.BaseURL
+	}
+	if override.PublicKey != "" {
+		base.PublicKey = override.PublicKey
+	}
+	if override.PrivateKey != "" {
+		base.PrivateKey = override.PrivateKey
+	}
+	if override.Credentials != nil {
+		base.Credentials = override.Credentials
+	}
+	if override.Retry != (RetryPolicy{}) {
+		base.Retry = override.Retry
+	}
+	if override.UserAgent != "" {
+		base.UserAgent = override.UserAgent
+	}
+	if len(override.Middleware) > 0 {
+		base.Middleware = append(append([]Middleware(nil), base.Middleware...), override.Middleware...)
+	}
+	if override.RateLimit != nil {
+		base.RateLimit = override.RateLimit
+	}
+	if override.CircuitBreaker != nil {
+		base.CircuitBreaker = override.CircuitBreaker
+	}
+	if override.Logger != nil {
+		base.Logger = override.Logger
+	}
+	if override.Log != (LogConfig{}) {
+		base.Log = override.Log
+	}
+	if override.Tracer != nil {
+		base.Tracer = override.Tracer
+	}
+	if override.Metrics != nil {
+		base.Metrics = override.Metrics
+	}
+	if len(override.MetricsLabels) > 0 {
+		labels := maps.Clone(base.MetricsLabels)
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		maps.Copy(labels, override.MetricsLabels)
+		base.MetricsLabels = labels
+	}
+	if override.OnLowQuota != nil {
+		base.OnLowQuota = override.OnLowQuota
+	}
+	if override.LowQuotaThreshold != 0 {
+		base.LowQuotaThreshold = override.LowQuotaThreshold
+	}
+	if override.APIVersion != "" {
+		base.APIVersion = override.APIVersion
+	}
+	if override.Routes != nil {
+		base.Routes = override.Routes
+	}
+	if override.Coalesce != nil {
+		base.Coalesce = override.Coalesce
+	}
+	if override.Cache != nil {
+		base.Cache = override.Cache
+	}
+	if override.Debug != nil {
+		base.Debug = override.Debug
+	}
+	if override.MaxResponseSize != 0 {
+		base.MaxResponseSize = override.MaxResponseSize
+	}
+	return base
+}
+
+// Lookup returns the client registered for merchantID.
+func (r *Registry) Lookup(merchantID string) (*Client, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	client, ok := r.clients[merchantID]
+	return client, ok
+}
+
+// For returns the client registered for merchantID, so calls can be routed
+// with For("merchant-a").CreateInvoice(...). For an unknown merchant it
+// returns a new client whose every call fails with ErrUnknownMerchant. That
+// client reuses the registry's http.Client, so it is cheap to build, and
+// changes made to it affect no other caller.
+func (r *Registry) For(merchantID string) *Client {
+	if client, ok := r.Lookup(merchantID); ok {
+		return client
+	}
+
+	err := fmt.Errorf("%w %q", ErrUnknownMerchant, merchantID)
+	return NewClient(ClientConfig{
+		HTTPClient: r.httpClient,
+		Middleware: []Middleware{func(Handler) Handler {
+			return func(context.Context, *Request) (*Response, error) {
+				return nil, err
+			}
+		}},
+	})
+}
+
+// Remove drops the client registered for merchantID.
+func (r *Registry) Remove(merchantID string) {
+	r.mu.Lock()
+	delete(r.clients, merchantID)
+	r.mu.Unlock()
+}
+
+// Merchants returns the registered merchant identifiers in sorted order.
+func (r *Registry) Merchants() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := make([]string, 0, len(r.clients))
+	for id := range r.clients {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
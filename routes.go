@@ -0,0 +1,118 @@
+package longswipe
+
+import (
+	"fmt"
+	"maps"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Route is the HTTP method and path of an SDK operation. Path is relative to
+// the client's base URL and API version prefix; placeholders such as
+// "{email}" are replaced with the call's escaped path parameters.
+type Route struct {
+	Method string
+	Path   string
+}
+
+// defaultRoutes maps every SDK operation to its endpoint.
+var defaultRoutes = map[string]Route{
+	"HealthCheck":                  {GET, "/merchant-integrations-server/health"},
+	"GetAllNetwork":                {GET, "/merchant-integrations/fetch-supported-cryptonetworks"},
+	"GetAllCurrency":               {GET, "/merchant-integrations/fetch-supported-currencies"},
+	"AddUser":                      {POST, "/merchant-integrations-server/add-new-user"},
+	"GetAllUser":                   {GET, "/merchant-integrations-server/fetch-merchant-users"},
+	"GetVoucherRedeemptionCharges": {POST, "/merchant-integrations/fetch-voucher-redemption-charges"},
+	"VerifyVoucher":                {POST, "/merchant-integrations/verify-voucher"},
+	"RedeemVoucher":                {POST, "/merchant-integrations/redeem-voucher"},
+	"FetchInvoice":                 {GET, "/merchant-integrations-server/fetch-invoice"},
+	"GetAllInvoiceCurrency":        {GET, "/merchant-integrations-server/fetch-all-allowed-invoice-Currency"},
+	"CreateInvoice":                {POST, "/merchant-integrations-server/create-invoice"},
+	"ApproveInvoice":               {POST, "/merchant-integrations-server/approve-invoice"},
+	"PaymentRequest":               {POST, "/merchant-integrations/payment-request"},
+	"AddressDepositRequest":        {POST, "/merchant-integrations/deposit-address-payment-request"},
+	"DepositCharges":               {POST, "/merchant-integrations/request-wallet-deposit-charges"},
+	"VerifyTransaction":            {GET, "/merchant-integrations-server/verify-transaction/{referenceId}"},
+	"ConfirmUser":                  {GET, "/merchant-integrations/confirm-user/{identifier}"},
+	"PayoutToLongSwipeUser":        {POST, "/merchant-integrations-server/payout"},
+	"AccountBalance":               {GET, "/merchant-integrations/fetch-balance"},
+	"GetCustomers":                 {GET, "/merchant-integrations-server/fetch-customers"},
+	"GetCustomer":                  {GET, "/merchant-integrations-server/fetch-customer-by-email/{email}"},
+	"AddCustomer":                  {POST, "/merchant-integrations-server/add-new-customer"},
+	"UpdateCustomer":               {PATCH, "/merchant-integrations-server/update-customer"},
+	"DeleteCustomer":               {DELETE, "/merchant-integrations-server/delete-customer/{customerId}"},
+	"GetCustomerTransactions":      {GET, "/merchant-integrations-server/fetch-customer-transactions/{customerId}"},
+}
+
+// DefaultRoutes returns the built-in route of every SDK operation, keyed by
+// operation name, as a starting point for ClientConfig.Routes.
+func DefaultRoutes() map[string]Route {
+	return maps.Clone(defaultRoutes)
+}
+
+var placeholderPattern = regexp.MustCompile(`\{[A-Za-z]+\}`)
+
+// buildRoutes merges overrides into the default routes.
+func buildRoutes(overrides map[string]Route) map[string]Route {
+	routes := maps.Clone(defaultRoutes)
+	for operation, route := range overrides {
+		routes[operation] = route
+	}
+	return routes
+}
+
+// checkRoutes fails for overrides of unknown operations and for overrides
+// whose placeholders differ from the built-in route's.
+func checkRoutes(overrides map[string]Route) error {
+	for _, operation := range sortedKeys(overrides) {
+		route := overrides[operation]
+		def, ok := defaultRoutes[operation]
+		if !ok {
+			return fmt.Errorf("Routes: unknown operation %q", operation)
+		}
+		if route.Method == "" || !strings.HasPrefix(route.Path, "/") {
+			return fmt.Errorf("Routes[%q]: method and absolute path are required", operation)
+		}
+		want := placeholderPattern.FindAllString(def.Path, -1)
+		got := placeholderPattern.FindAllString(route.Path, -1)
+		slices.Sort(want)
+		slices.Sort(got)
+		if !slices.Equal(want, got) {
+			return fmt.Errorf("Routes[%q]: path must use the placeholders %v", operation, want)
+		}
+	}
+	return nil
+}
+
+// knownOperation reports whether operation names a built-in SDK method or Do.
+func knownOperation(operation string) bool {
+	_, ok := defaultRoutes[operation]
+	return ok || operation == DoOperation
+}
+
+// endpoint returns the method and path of operation with params substituted
+// into its placeholders and query, if non-empty, appended.
+func (c *Client) endpoint(operation string, params map[string]string, query url.Values) (string, string) {
+	route := c.routes[operation]
+
+	path := placeholderPattern.ReplaceAllStringFunc(route.Path, func(placeholder string) string {
+		value := params[placeholder[1:len(placeholder)-1]]
+		// PathEscape leaves "+" alone, but some servers decode it as a space.
+		return strings.ReplaceAll(url.PathEscape(value), "+", "%2B")
+	})
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	return route.Method, path
+}
+
+// versionPrefix normalises ClientConfig.APIVersion to "/<version>", or "".
+func versionPrefix(version string) string {
+	version = strings.Trim(version, "/")
+	if version == "" {
+		return ""
+	}
+	return "/" + version
+}
@@ -0,0 +1,130 @@
+package longswipe
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Hosts of the LongSwipe API, for use with PinSet.
+const (
+	ProductionHost = "api.longswipe.com"
+	SandboxHost    = "sandbox.api.longswipe.com"
+)
+
+// ErrPinMismatch is returned when no certificate in the verified chain of a
+// pinned host matches its pins.
+var ErrPinMismatch = errors.New("longswipe: certificate pin mismatch")
+
+// SPKIPin returns the pin of cert: the base64-encoded SHA-256 digest of its
+// DER-encoded SubjectPublicKeyInfo, as used by PinSet.
+func SPKIPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// PinSet holds the SPKI pins of each pinned host. Hosts are matched on the TLS
+// server name, so IP addresses cannot be pinned. A connection to a pinned host
+// succeeds only if a certificate in its chain matches one of the host's pins;
+// hosts without pins are not checked. Pin the current key together with a
+// backup key so certificates can be rotated without an outage, and call Set
+// to roll the pins forward while the client is running.
+//
+// Pinning cannot be combined with a TLS-intercepting proxy unless the
+// proxy's own key is pinned.
+type PinSet struct {
+	mu   sync.RWMutex
+	pins map[string]map[string]bool
+}
+
+// NewPinSet returns an empty pin set.
+func NewPinSet() *PinSet {
+	return &PinSet{pins: make(map[string]map[string]bool)}
+}
+
+// Set replaces the pins of host. Pins are base64-encoded SHA-256 SPKI
+// digests, optionally prefixed with "sha256/". Calling Set without pins stops
+// pinning host.
+func (p *PinSet) Set(host string, pins ...string) error {
+	set := make(map[string]bool, len(pins))
+	for _, pin := range pins {
+		pin = strings.TrimPrefix(pin, "sha256/")
+		if raw, err := base64.StdEncoding.DecodeString(pin); err != nil || len(raw) != sha256.Size {
+			return fmt.Errorf("longswipe: invalid pin %q for %s: must be a base64-encoded SHA-256 digest", pin, host)
+		}
+		set[pin] = true
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(set) == 0 {
+		delete(p.pins, host)
+		return nil
+	}
+	p.pins[host] = set
+	return nil
+}
+
+// verify is installed as tls.Config.VerifyConnection and runs after the
+// standard chain verification. Pins are matched against the verified chains
+// only, since a server may send any extra certificate alongside its own. When
+// verification is skipped there are no verified chains and only the leaf
+// certificate is matched.
+func (p *PinSet) verify(cs tls.ConnectionState) error {
+	p.mu.RLock()
+	pins := p.pins[cs.ServerName]
+	p.mu.RUnlock()
+	if pins == nil {
+		return nil
+	}
+
+	var certs []*x509.Certificate
+	for _, chain := range cs.VerifiedChains {
+		certs = append(certs, chain...)
+	}
+	if len(cs.VerifiedChains) == 0 && len(cs.PeerCertificates) > 0 {
+		certs = cs.PeerCertificates[:1]
+	}
+	for _, cert := range certs {
+		if pins[SPKIPin(cert)] {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w for %s", ErrPinMismatch, cs.ServerName)
+}
+
+// buildTLSConfig returns the TLS configuration of the SDK-built transport.
+func buildTLSConfig(config ClientConfig) *tls.Config {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+	if config.TLSConfig != nil {
+		tlsConfig = config.TLSConfig.Clone()
+	}
+
+	if config.RootCAs != nil {
+		tlsConfig.RootCAs = config.RootCAs
+	}
+	if len(config.ClientCertificates) > 0 {
+		certs := make([]tls.Certificate, 0, len(tlsConfig.Certificates)+len(config.ClientCertificates))
+		certs = append(certs, tlsConfig.Certificates...)
+		tlsConfig.Certificates = append(certs, config.ClientCertificates...)
+	}
+	if pins := config.Pins; pins != nil {
+		next := tlsConfig.VerifyConnection
+		tlsConfig.VerifyConnection = func(cs tls.ConnectionState) error {
+			if next != nil {
+				if err := next(cs); err != nil {
+					return err
+				}
+			}
+			return pins.verify(cs)
+		}
+	}
+	return tlsConfig
+}
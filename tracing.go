@@ -0,0 +1,120 @@
+package longswipe
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"path"
+	"regexp"
+)
+
+// Attribute is a key/value pair attached to a span.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// Attribute keys set by the client on its spans.
+const (
+	AttrOperation      = "longswipe.operation"
+	AttrEndpoint       = "longswipe.endpoint"
+	AttrReferenceID    = "longswipe.reference_id"
+	AttrIdempotencyKey = "longswipe.idempotency_key"
+	AttrAttempts       = "longswipe.attempts"
+	AttrHTTPMethod     = "http.request.method"
+	AttrHTTPStatus     = "http.response.status_code"
+)
+
+// Tracer starts a span around every SDK call. It is deliberately small so it
+// can be adapted to any tracing library.
+type Tracer interface {
+	// StartSpan starts a span named name as a child of any span in ctx and
+	// returns a context carrying the new span.
+	StartSpan(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+}
+
+// Span is a span started by a Tracer.
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	// TraceParent returns the W3C traceparent header value identifying the
+	// span, or "" if the span cannot be propagated.
+	TraceParent() string
+	// End finishes the span. err is the error returned by the call, if any.
+	End(err error)
+}
+
+type traceParentCtxKey struct{}
+
+var traceParentPattern = regexp.MustCompile(`^[0-9a-f]{2}-[0-9a-f]{32}-[0-9a-f]{16}-[0-9a-f]{2}$`)
+
+// WithTraceParent returns a copy of ctx whose calls send traceparent as the
+// W3C traceparent header when no Tracer span provides one. Invalid values are
+// ignored.
+func WithTraceParent(ctx context.Context, traceparent string) context.Context {
+	return context.WithValue(ctx, traceParentCtxKey{}, traceparent)
+}
+
+// startSpan starts the span for req and sets its traceparent header.
+func (c *Client) startSpan(ctx context.Context, req *Request) (context.Context, Span) {
+	var span Span
+	if c.tracer != nil {
+		attrs := []Attribute{
+			{Key: AttrOperation, Value: req.Operation},
+			{Key: AttrHTTPMethod, Value: req.Method},
+			{Key: AttrEndpoint, Value: req.Path},
+		}
+		if ref := referenceID(req); ref != "" {
+			attrs = append(attrs, Attribute{Key: AttrReferenceID, Value: ref})
+		}
+		if key := req.Header.Get("Idempotency-Key"); key != "" {
+			attrs = append(attrs, Attribute{Key: AttrIdempotencyKey, Value: key})
+		}
+		ctx, span = c.tracer.StartSpan(ctx, "longswipe."+req.Operation, attrs...)
+	}
+
+	traceparent := ""
+	if span != nil {
+		traceparent = span.TraceParent()
+	}
+	if traceparent == "" {
+		traceparent, _ = ctx.Value(traceParentCtxKey{}).(string)
+	}
+	if traceParentPattern.MatchString(traceparent) {
+		req.Header.Set("traceparent", traceparent)
+	}
+	return ctx, span
+}
+
+func endSpan(span Span, resp *Response, err error) {
+	if span == nil {
+		return
+	}
+	if resp != nil {
+		span.SetAttributes(Attribute{Key: AttrHTTPStatus, Value: resp.StatusCode})
+	}
+	var reqErr *RequestError
+	if errors.As(err, &reqErr) {
+		span.SetAttributes(Attribute{Key: AttrAttempts, Value: reqErr.Attempts})
+	}
+	span.End(err)
+}
+
+// referenceID extracts the merchant reference of a call, if it has one.
+func referenceID(req *Request) string {
+	switch body := req.Body.(type) {
+	case *PaymentRequest:
+		return body.ReferenceID
+	case *AddressDepositRequest:
+		return body.ReferenceID
+	case *RedeemRequest:
+		return body.ReferenceId
+	case *CustomerPayout:
+		return body.ReferenceId
+	}
+	if req.Operation == "VerifyTransaction" {
+		if ref, err := url.PathUnescape(path.Base(req.Path)); err == nil {
+			return ref
+		}
+	}
+	return ""
+}
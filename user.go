@@ -1,11 +1,19 @@
 package longswipe
 
+import "context"
+
 func (c *Client) AddUser(body *AddNewUserRequest) (*SuccessResponse, error) {
-	endpoint := "/merchant-integrations-server/add-new-user"
+	return c.AddUserWithContext(context.Background(), body)
+}
+
+func (c *Client) AddUserWithContext(ctx context.Context, body *AddNewUserRequest) (*SuccessResponse, error) {
+	method, endpoint := c.endpoint("AddUser", nil, nil)
 	var res SuccessResponse
 
 	_, err := c.doRequestAndUnmarshal(
-		POST,
+		ctx,
+		"AddUser",
+		method,
 		endpoint,
 		body,
 		&res,
@@ -18,11 +26,17 @@ func (c *Client) AddUser(body *AddNewUserRequest) (*SuccessResponse, error) {
 }
 
 func (c *Client) GetAllUser() (*MerchantUserResponse, error) {
-	endpoint := "/merchant-integrations-server/fetch-merchant-users"
+	return c.GetAllUserWithContext(context.Background())
+}
+
+func (c *Client) GetAllUserWithContext(ctx context.Context) (*MerchantUserResponse, error) {
+	method, endpoint := c.endpoint("GetAllUser", nil, nil)
 	var user MerchantUserResponse
 
 	_, err := c.doRequestAndUnmarshal(
-		GET,
+		ctx,
+		"GetAllUser",
+		method,
 		endpoint,
 		nil,
 		&user,
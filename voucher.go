@@ -1,10 +1,18 @@
 package longswipe
 
+import "context"
+
 func (c *Client) GetVoucherRedeemptionCharges(body *RedeemRequest) (*RedeemeVoucherDetailsResponse, error) {
-	endpoint := "/merchant-integrations/fetch-voucher-redemption-charges"
+	return c.GetVoucherRedeemptionChargesWithContext(context.Background(), body)
+}
+
+func (c *Client) GetVoucherRedeemptionChargesWithContext(ctx context.Context, body *RedeemRequest) (*RedeemeVoucherDetailsResponse, error) {
+	method, endpoint := c.endpoint("GetVoucherRedeemptionCharges", nil, nil)
 	var charges RedeemeVoucherDetailsResponse
 	_, err := c.doRequestAndUnmarshal(
-		POST,
+		ctx,
+		"GetVoucherRedeemptionCharges",
+		method,
 		endpoint,
 		body,
 		&charges,
@@ -17,11 +25,17 @@ func (c *Client) GetVoucherRedeemptionCharges(body *RedeemRequest) (*RedeemeVouc
 }
 
 func (c *Client) VerifyVoucher(body *VerifyVoucherCodeRequest) (*VerifyVoucherResponse, error) {
+	return c.VerifyVoucherWithContext(context.Background(), body)
+}
+
+func (c *Client) VerifyVoucherWithContext(ctx context.Context, body *VerifyVoucherCodeRequest) (*VerifyVoucherResponse, error) {
 	var verifyVoucher VerifyVoucherResponse
 
-	endpoint := "/merchant-integrations/verify-voucher"
+	method, endpoint := c.endpoint("VerifyVoucher", nil, nil)
 	_, err := c.doRequestAndUnmarshal(
-		POST,
+		ctx,
+		"VerifyVoucher",
+		method,
 		endpoint,
 		body,
 		&verifyVoucher,
@@ -34,11 +48,26 @@ func (c *Client) VerifyVoucher(body *VerifyVoucherCodeRequest) (*VerifyVoucherRe
 }
 
 func (c *Client) RedeemVoucher(body *RedeemRequest) (*SuccessResponse, error) {
-	endpoint := "/merchant-integrations/redeem-voucher"
+	return c.RedeemVoucherWithContext(context.Background(), body)
+}
+
+func (c *Client) RedeemVoucherWithContext(ctx context.Context, body *RedeemRequest) (*SuccessResponse, error) {
+	method, endpoint := c.endpoint("RedeemVoucher", nil, nil)
 	var redeemVoucher SuccessResponse
 
-	_, err := c.doRequestAndUnmarshal(
-		POST,
+	var suppliedKey string
+	if body != nil {
+		suppliedKey = body.IdempotencyKey
+	}
+	ctx, idempotencyKey, err := ensureIdempotencyKey(ctx, suppliedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = c.doRequestAndUnmarshal(
+		ctx,
+		"RedeemVoucher",
+		method,
 		endpoint,
 		body,
 		&redeemVoucher,
@@ -47,5 +76,6 @@ func (c *Client) RedeemVoucher(body *RedeemRequest) (*SuccessResponse, error) {
 	if err != nil {
 		return nil, err
 	}
+	redeemVoucher.IdempotencyKey = idempotencyKey
 	return &redeemVoucher, nil
 }